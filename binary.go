@@ -0,0 +1,60 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"fmt"
+	"io"
+)
+
+// ReadBinary reads raw binary data from r into a new image starting at
+// address.
+func ReadBinary(r io.Reader, address uint32) (*Image, error) {
+	return ReadBinarySparse(r, address, 0, 0)
+}
+
+// ReadBinarySparse is like ReadBinary but leaves out every run of at least
+// minRun consecutive fill bytes, so that padding does not end up in the image.
+// A minRun of 0 keeps all data.
+func ReadBinarySparse(r io.Reader, address uint32, fill byte, minRun int) (*Image, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(address)+uint64(len(data)) > addressSpaceSize {
+		return nil, fmt.Errorf("%d bytes at 0x%08X extend past the 32-bit address space", len(data), address)
+	}
+
+	img := new(Image)
+	if minRun <= 0 {
+		_, err = img.WriteAt(data, int64(address))
+		return img, err
+	}
+
+	// Write the data between the runs of fill bytes
+	start := 0
+	for i := 0; i < len(data); {
+		if data[i] != fill {
+			i++
+			continue
+		}
+
+		j := i
+		for j < len(data) && data[j] == fill {
+			j++
+		}
+		if j-i >= minRun {
+			if _, err := img.WriteAt(data[start:i], int64(address)+int64(start)); err != nil {
+				return nil, err
+			}
+			start = j
+		}
+		i = j
+	}
+	if _, err := img.WriteAt(data[start:], int64(address)+int64(start)); err != nil {
+		return nil, err
+	}
+
+	return img, nil
+}
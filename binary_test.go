@@ -0,0 +1,50 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReadBinarySparse(t *testing.T) {
+	data := decodeHex("0102FFFF0304FFFFFFFF0506FF")
+
+	var cases = []struct {
+		minRun int
+		ranges []Range
+	}{
+		{0, []Range{{0x1000, 0x100C}}},
+		{2, []Range{{0x1000, 0x1001}, {0x1004, 0x1005}, {0x100A, 0x100C}}},
+		{3, []Range{{0x1000, 0x1005}, {0x100A, 0x100C}}},
+		{1, []Range{{0x1000, 0x1001}, {0x1004, 0x1005}, {0x100A, 0x100B}}},
+	}
+
+	for i, tc := range cases {
+		t.Logf("Case %d", i)
+
+		img, err := ReadBinarySparse(bytes.NewReader(data), 0x1000, 0xFF, tc.minRun)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+
+		ranges := img.Ranges()
+		if len(ranges) != len(tc.ranges) {
+			t.Errorf("range mismatch: expected=%v, actual=%v", tc.ranges, ranges)
+			continue
+		}
+		for j := range ranges {
+			if ranges[j] != tc.ranges[j] {
+				t.Errorf("    [range %d] mismatch: expected=%v, actual=%v", j, tc.ranges[j], ranges[j])
+			}
+		}
+	}
+}
+
+func TestReadBinaryOutOfRange(t *testing.T) {
+	if _, err := ReadBinary(bytes.NewReader(make([]byte, 16)), 0xFFFFFFF8); err == nil {
+		t.Error("expected error for data past the end of the address space")
+	}
+}
@@ -0,0 +1,295 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// DiffKind is the way an address range differs between two images.
+type DiffKind int
+
+const (
+	// DiffChanged is data present in both images with different values.
+	DiffChanged DiffKind = iota
+	// DiffOnlyInA is data present only in the first image.
+	DiffOnlyInA
+	// DiffOnlyInB is data present only in the second image.
+	DiffOnlyInB
+)
+
+var diffKindNames = []string{
+	DiffChanged: "changed",
+	DiffOnlyInA: "only-a",
+	DiffOnlyInB: "only-b",
+}
+
+func (k DiffKind) String() string {
+	if k < 0 || int(k) >= len(diffKindNames) {
+		return fmt.Sprintf("DiffKind(%d)", int(k))
+	}
+	return diffKindNames[k]
+}
+
+func (k DiffKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// DiffRange is a range of addresses that differs between two images.
+type DiffRange struct {
+	Kind  DiffKind
+	Range Range
+	// A and B are the data of the range in the first and second image, nil
+	// if the image holds no data there.
+	A []byte
+	B []byte
+}
+
+// Diff is the difference between the memory contents of two images.
+type Diff struct {
+	// Ranges are the differing address ranges in ascending order.
+	Ranges []DiffRange
+	// StartA and StartB are the start addresses of the two images.
+	StartA *StartAddress
+	StartB *StartAddress
+}
+
+// Compare returns the differences between the memory contents and start
+// addresses of images a and b. How the data was split into records or
+// segments doesn't matter.
+func Compare(a, b *Image) *Diff {
+	d := &Diff{
+		Ranges: make([]DiffRange, 0),
+		StartA: a.Start,
+		StartB: b.Start,
+	}
+
+	var (
+		sa, sb = a.segments, b.segments
+		i, j   int
+		next   uint64
+	)
+	for {
+		for i < len(sa) && sa[i].end() <= next {
+			i++
+		}
+		for j < len(sb) && sb[j].end() <= next {
+			j++
+		}
+		if i == len(sa) && j == len(sb) {
+			break
+		}
+
+		// Find the end of the run of addresses where both images either do or
+		// don't hold data
+		var (
+			inA = i < len(sa) && uint64(sa[i].Address) <= next
+			inB = j < len(sb) && uint64(sb[j].Address) <= next
+			end = uint64(addressSpaceSize)
+		)
+		if i < len(sa) {
+			end = minEnd(end, inA, sa[i])
+		}
+		if j < len(sb) {
+			end = minEnd(end, inB, sb[j])
+		}
+
+		switch {
+		case inA && inB:
+			var (
+				dataA = sa[i].Data[next-uint64(sa[i].Address) : end-uint64(sa[i].Address)]
+				dataB = sb[j].Data[next-uint64(sb[j].Address) : end-uint64(sb[j].Address)]
+			)
+			for k := 0; k < len(dataA); k++ {
+				if dataA[k] != dataB[k] {
+					d.add(DiffChanged, next+uint64(k), dataA[k:k+1], dataB[k:k+1])
+				}
+			}
+		case inA:
+			d.add(DiffOnlyInA, next, sa[i].Data[next-uint64(sa[i].Address):end-uint64(sa[i].Address)], nil)
+		case inB:
+			d.add(DiffOnlyInB, next, nil, sb[j].Data[next-uint64(sb[j].Address):end-uint64(sb[j].Address)])
+		}
+		next = end
+	}
+
+	return d
+}
+
+// minEnd returns the lower of end and the next boundary of s, its end if the
+// current address is within it and otherwise its start.
+func minEnd(end uint64, within bool, s *Segment) uint64 {
+	boundary := uint64(s.Address)
+	if within {
+		boundary = s.end()
+	}
+	if boundary < end {
+		return boundary
+	}
+	return end
+}
+
+// add appends a copy of the differing data at address, joining it to the last
+// range if it is of the same kind and adjacent.
+func (d *Diff) add(kind DiffKind, address uint64, a, b []byte) {
+	size := maxAddress(uint32(len(a)), uint32(len(b)))
+	if n := len(d.Ranges); n > 0 {
+		last := &d.Ranges[n-1]
+		if last.Kind == kind && uint64(last.Range.High)+1 == address {
+			last.Range.High += size
+			last.A = append(last.A, a...)
+			last.B = append(last.B, b...)
+			return
+		}
+	}
+
+	r := DiffRange{
+		Kind:  kind,
+		Range: Range{uint32(address), uint32(address) + size - 1},
+	}
+	if a != nil {
+		r.A = append([]byte(nil), a...)
+	}
+	if b != nil {
+		r.B = append([]byte(nil), b...)
+	}
+	d.Ranges = append(d.Ranges, r)
+}
+
+// StartChanged returns true if the images have different start addresses.
+func (d *Diff) StartChanged() bool {
+	if d.StartA == nil || d.StartB == nil {
+		return d.StartA != d.StartB
+	}
+	return *d.StartA != *d.StartB
+}
+
+// Equal returns true if the images have the same memory contents and start
+// address.
+func (d *Diff) Equal() bool {
+	return len(d.Ranges) == 0 && !d.StartChanged()
+}
+
+// WriteSummary writes one line for each differing address range and for a
+// changed start address, naming the images nameA and nameB.
+func (d *Diff) WriteSummary(w io.Writer, nameA, nameB string) error {
+	return d.write(w, nameA, nameB, false)
+}
+
+// WriteText is like WriteSummary but follows each line with a hex dump of the
+// differing data, prefixed with - for the first image and + for the second.
+func (d *Diff) WriteText(w io.Writer, nameA, nameB string) error {
+	return d.write(w, nameA, nameB, true)
+}
+
+func (d *Diff) write(w io.Writer, nameA, nameB string, dump bool) error {
+	for _, r := range d.Ranges {
+		var err error
+		switch r.Kind {
+		case DiffChanged:
+			_, err = fmt.Fprintf(w, "! %v  differs\n", r.Range)
+		case DiffOnlyInA:
+			_, err = fmt.Fprintf(w, "- %v  only in %s\n", r.Range, nameA)
+		case DiffOnlyInB:
+			_, err = fmt.Fprintf(w, "+ %v  only in %s\n", r.Range, nameB)
+		}
+		if err != nil {
+			return err
+		}
+		if !dump {
+			continue
+		}
+		if err := writeDiffLines(w, '-', r.Range.Low, r.A); err != nil {
+			return err
+		}
+		if err := writeDiffLines(w, '+', r.Range.Low, r.B); err != nil {
+			return err
+		}
+	}
+
+	if d.StartChanged() {
+		startString := func(sa *StartAddress) string {
+			if sa == nil {
+				return "none"
+			}
+			return sa.String()
+		}
+		if _, err := fmt.Fprintf(w, "! start address %s in %s, %s in %s\n", startString(d.StartA), nameA, startString(d.StartB), nameB); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffLineWidth is the number of bytes per line of the hex dumps written by
+// WriteText.
+const diffLineWidth = 16
+
+func writeDiffLines(w io.Writer, prefix byte, address uint32, data []byte) error {
+	for i := 0; i < len(data); i += diffLineWidth {
+		line := data[i:]
+		if len(line) > diffLineWidth {
+			line = line[:diffLineWidth]
+		}
+		if _, err := fmt.Fprintf(w, "%c %08X: % X\n", prefix, address+uint32(i), line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarshalJSON encodes the diff with addresses and data as hex strings.
+func (d *Diff) MarshalJSON() ([]byte, error) {
+	type jsonRange struct {
+		Kind DiffKind `json:"kind"`
+		Low  string   `json:"low"`
+		High string   `json:"high"`
+		Size uint64   `json:"size"`
+		A    *string  `json:"a,omitempty"`
+		B    *string  `json:"b,omitempty"`
+	}
+	var v struct {
+		Equal        bool        `json:"equal"`
+		Ranges       []jsonRange `json:"ranges"`
+		StartA       *string     `json:"start_a"`
+		StartB       *string     `json:"start_b"`
+		StartChanged bool        `json:"start_changed"`
+	}
+
+	hexString := func(data []byte) *string {
+		if data == nil {
+			return nil
+		}
+		s := hex.EncodeToString(data)
+		return &s
+	}
+	startString := func(sa *StartAddress) *string {
+		if sa == nil {
+			return nil
+		}
+		s := sa.String()
+		return &s
+	}
+
+	v.Equal = d.Equal()
+	v.Ranges = make([]jsonRange, len(d.Ranges))
+	for i, r := range d.Ranges {
+		v.Ranges[i] = jsonRange{
+			Kind: r.Kind,
+			Low:  fmt.Sprintf("0x%08X", r.Range.Low),
+			High: fmt.Sprintf("0x%08X", r.Range.High),
+			Size: uint64(r.Range.High-r.Range.Low) + 1,
+			A:    hexString(r.A),
+			B:    hexString(r.B),
+		}
+	}
+	v.StartA = startString(d.StartA)
+	v.StartB = startString(d.StartB)
+	v.StartChanged = d.StartChanged()
+
+	return json.Marshal(&v)
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	var a, b Image
+	a.WriteAt(decodeHex("0102030405060708"), 0x1000)
+	a.WriteAt(decodeHex("AABB"), 0x2000)
+	b.WriteAt(decodeHex("0102"), 0x1000)
+	b.WriteAt(decodeHex("FF04FFFF07"), 0x1002)
+	b.WriteAt(decodeHex("CC"), 0x3000)
+	b.Start = NewStartLinearAddress(0x1000)
+
+	d := Compare(&a, &b)
+	expected := []DiffRange{
+		{DiffChanged, Range{0x1002, 0x1002}, decodeHex("03"), decodeHex("FF")},
+		{DiffChanged, Range{0x1004, 0x1005}, decodeHex("0506"), decodeHex("FFFF")},
+		{DiffOnlyInA, Range{0x1007, 0x1007}, decodeHex("08"), nil},
+		{DiffOnlyInA, Range{0x2000, 0x2001}, decodeHex("AABB"), nil},
+		{DiffOnlyInB, Range{0x3000, 0x3000}, nil, decodeHex("CC")},
+	}
+	if len(d.Ranges) != len(expected) {
+		t.Fatalf("diff mismatch: expected=%v, actual=%v", expected, d.Ranges)
+	}
+	for i, e := range expected {
+		r := d.Ranges[i]
+		if r.Kind != e.Kind || r.Range != e.Range || !bytes.Equal(r.A, e.A) || !bytes.Equal(r.B, e.B) || (r.A == nil) != (e.A == nil) {
+			t.Errorf("diff mismatch: expected=%v, actual=%v", e, r)
+		}
+	}
+	if !d.StartChanged() || d.Equal() {
+		t.Errorf("expected start address change")
+	}
+
+	var buf bytes.Buffer
+	d.WriteText(&buf, "a.hex", "b.hex")
+	expectedText := `! 0x00001002-0x00001002  differs
+- 00001002: 03
++ 00001002: FF
+! 0x00001004-0x00001005  differs
+- 00001004: 05 06
++ 00001004: FF FF
+- 0x00001007-0x00001007  only in a.hex
+- 00001007: 08
+- 0x00002000-0x00002001  only in a.hex
+- 00002000: AA BB
++ 0x00003000-0x00003000  only in b.hex
++ 00003000: CC
+! start address none in a.hex, 0x00001000 in b.hex
+`
+	if buf.String() != expectedText {
+		t.Errorf("text mismatch: expected=\n%s\nactual=\n%s", expectedText, buf.String())
+	}
+
+	data, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(data), `{"kind":"only-b","low":"0x00003000","high":"0x00003000","size":1,"b":"cc"}`) {
+		t.Errorf("JSON mismatch: %s", data)
+	}
+}
+
+func TestCompareEqual(t *testing.T) {
+	var a, b Image
+	a.WriteAt(decodeHex("01020304"), 0xFFFFFFFC)
+	b.WriteAt(decodeHex("0102"), 0xFFFFFFFC)
+	b.WriteAt(decodeHex("0304"), 0xFFFFFFFE)
+
+	if d := Compare(&a, &b); !d.Equal() {
+		t.Errorf("expected equal images but got %v", d.Ranges)
+	}
+}
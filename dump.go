@@ -0,0 +1,113 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Dumper writes a hex dump of an image with absolute addresses. Lines are
+// aligned to the width and addresses without data are left blank. Gaps
+// spanning whole lines are collapsed into a single line starting with *. The
+// exported fields can be changed to customize the output before calling Dump.
+type Dumper struct {
+	w io.Writer
+
+	// Width is the number of bytes per line.
+	Width int
+	// Group is the number of bytes written without a space between them.
+	Group int
+	// ASCII adds a column with the printable ASCII characters of each line.
+	ASCII bool
+}
+
+// NewDumper returns a dumper writing to w with 16 bytes per line, single byte
+// groups and an ASCII column.
+func NewDumper(w io.Writer) *Dumper {
+	return &Dumper{
+		w:     w,
+		Width: 16,
+		Group: 1,
+		ASCII: true,
+	}
+}
+
+// Dump writes a hex dump of the image's data.
+func (d *Dumper) Dump(img *Image) error {
+	if d.Width < 1 {
+		return fmt.Errorf("dump width must be at least 1 but got %d", d.Width)
+	}
+	if d.Group < 1 {
+		return fmt.Errorf("dump group must be at least 1 but got %d", d.Group)
+	}
+
+	var (
+		w        = bufio.NewWriter(d.w)
+		width    = uint64(d.Width)
+		lastLine uint64
+		printed  bool
+		data     = make([]byte, d.Width)
+		present  = make([]bool, d.Width)
+	)
+	for k, s := range img.segments {
+		for line := uint64(s.Address) - uint64(s.Address)%width; line < s.end(); line += width {
+			if printed && line <= lastLine {
+				continue // already written with the previous segment
+			}
+			if printed && line > lastLine+width {
+				fmt.Fprintf(w, "*         %v  no data\n", Range{uint32(img.segments[k-1].end()), s.Address - 1})
+			}
+
+			for i := range data {
+				_, err := img.ReadAt(data[i:i+1], int64(line)+int64(i))
+				present[i] = err == nil
+			}
+			d.writeLine(w, line, data, present)
+			lastLine, printed = line, true
+		}
+	}
+	return w.Flush()
+}
+
+func (d *Dumper) writeLine(w *bufio.Writer, address uint64, data []byte, present []bool) {
+	line := make([]byte, 0, 10+3*len(data)+len(data)+4)
+	line = append(line, fmt.Sprintf("%08X:", address)...)
+	for i, b := range data {
+		if i%d.Group == 0 {
+			line = append(line, ' ')
+		}
+		if present[i] {
+			line = append(line, fmt.Sprintf("%02X", b)...)
+		} else {
+			line = append(line, "  "...)
+		}
+	}
+
+	if d.ASCII {
+		line = append(line, "  |"...)
+		for i, b := range data {
+			switch {
+			case !present[i]:
+				line = append(line, ' ')
+			case b >= 0x20 && b < 0x7F:
+				line = append(line, b)
+			default:
+				line = append(line, '.')
+			}
+		}
+		line = append(line, '|')
+	} else {
+		line = bytes.TrimRight(line, " ")
+	}
+
+	w.Write(append(line, '\n'))
+}
+
+// Dump writes a hex dump of the image with the options of NewDumper.
+func (img *Image) Dump(w io.Writer) error {
+	return NewDumper(w).Dump(img)
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDumper(t *testing.T) {
+	var img Image
+	img.WriteAt([]byte("Hello"), 0x1003)
+	img.WriteAt(decodeHex("0001"), 0x100A)
+	img.WriteAt(decodeHex("FF"), 0x2000)
+	img.WriteAt(decodeHex("AABB"), 0xFFFFFFFE)
+
+	var cases = []struct {
+		width  int
+		group  int
+		ascii  bool
+		output string
+	}{
+		{
+			width: 16,
+			group: 1,
+			ascii: true,
+			output: "00001000:          48 65 6C 6C 6F       00 01              |   Hello  ..    |\n" +
+				"*         0x0000100C-0x00001FFF  no data\n" +
+				"00002000: FF                                               |.               |\n" +
+				"*         0x00002001-0xFFFFFFFD  no data\n" +
+				"FFFFFFF0:                                           AA BB  |              ..|\n",
+		},
+		{
+			width: 8,
+			group: 4,
+			output: "00001000:       48 656C6C6F\n" +
+				"00001008:     0001\n" +
+				"*         0x0000100C-0x00001FFF  no data\n" +
+				"00002000: FF\n" +
+				"*         0x00002001-0xFFFFFFFD  no data\n" +
+				"FFFFFFF8:              AABB\n",
+		},
+	}
+
+	for i, tc := range cases {
+		t.Logf("Case %d", i)
+
+		var (
+			buf bytes.Buffer
+			d   = NewDumper(&buf)
+		)
+		d.Width, d.Group, d.ASCII = tc.width, tc.group, tc.ascii
+		if err := d.Dump(&img); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if buf.String() != tc.output {
+			t.Errorf("output mismatch: expected=\n%q\nactual=\n%q", tc.output, buf.String())
+		}
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Addressing selects the kind of address record used to reach addresses above
+// 64 KiB.
+type Addressing int
+
+const (
+	// LinearAddressing uses extended linear address records and can address
+	// the full 32-bit address space.
+	LinearAddressing Addressing = iota
+	// SegmentAddressing uses extended segment address records and can address
+	// the first 1 MiB.
+	SegmentAddressing
+)
+
+// maxSegmentAddress is the highest address reachable with SegmentAddressing.
+const maxSegmentAddress = 0xFFFFF
+
+// Encoder writes segments in Intel HEX format. The exported fields can be
+// changed to customize the output before the first call to Encode.
+type Encoder struct {
+	w io.Writer
+
+	// RecordSize is the maximum number of data bytes per data record,
+	// between 1 and MaxRecordSize.
+	RecordSize int
+	// LowerCase writes hex digits in lower case.
+	LowerCase bool
+	// UseCRLF ends lines with \r\n instead of \n.
+	UseCRLF bool
+	// Addressing selects extended linear or extended segment address records.
+	Addressing Addressing
+	// FlatAddressing lets data records run past the end of a 64 KiB segment
+	// instead of splitting them at the boundary. Readers following the
+	// Intel HEX specification wrap such data around to the start of the
+	// segment, so this should only be used for tools that expect it.
+	FlatAddressing bool
+	// InitialAddressRecord writes an address record before the first data
+	// record even if its address base is 0.
+	InitialAddressRecord bool
+	// StartAddress is written just before the EOF record if it is not nil.
+	StartAddress *StartAddress
+}
+
+// NewEncoder returns an encoder writing to w with DefaultRecordSize records,
+// upper case hex digits, \n line endings and linear addressing.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		w:          w,
+		RecordSize: DefaultRecordSize,
+	}
+}
+
+// Encode writes the segments, in order, followed by the start address record
+// if any and an EOF record.
+func (e *Encoder) Encode(segments SegmentSlice) error {
+	if e.RecordSize < 1 || e.RecordSize > MaxRecordSize {
+		return fmt.Errorf("record size must be between 1 and %d but got %d", MaxRecordSize, e.RecordSize)
+	}
+
+	// Keep track of the address base, forcing the first address record if
+	// required
+	var (
+		addressBase     uint32
+		haveAddressBase = !e.InitialAddressRecord
+	)
+
+	for _, seg := range segments {
+		if seg.end() > addressSpaceSize {
+			return fmt.Errorf("segment at 0x%08X extends past the 32-bit address space", seg.Address)
+		}
+		if e.Addressing == SegmentAddressing && len(seg.Data) > 0 && seg.end()-1 > maxSegmentAddress {
+			return fmt.Errorf("segment at 0x%08X extends past the 1 MiB reachable with segment addressing", seg.Address)
+		}
+
+		for offset := 0; offset < len(seg.Data); {
+			address := seg.Address + uint32(offset)
+
+			// Check if we need to output a new address base
+			base := address &^ 0xFFFF
+			if !haveAddressBase || base != addressBase {
+				// Save the base so we don't write the extended record multiple times
+				addressBase = base
+				haveAddressBase = true
+
+				if err := e.writeRecord(e.addressRecord(base)); err != nil {
+					return err
+				}
+			}
+
+			// Limit the record to the record size and to the end of the
+			// current 64 KiB segment
+			n := len(seg.Data) - offset
+			if n > e.RecordSize {
+				n = e.RecordSize
+			}
+			if left := 0x10000 - int(address&0xFFFF); n > left && !e.FlatAddressing {
+				n = left
+			}
+
+			// Write the data record
+			record := NewRecord(RecordTypeData, uint16(address&0xFFFF), seg.Data[offset:offset+n])
+			if err := e.writeRecord(record); err != nil {
+				return err
+			}
+			offset += n
+		}
+	}
+
+	// Write the start address record
+	if e.StartAddress != nil {
+		if err := e.writeRecord(e.StartAddress.Record()); err != nil {
+			return err
+		}
+	}
+
+	// Write the EOF record
+	return e.writeRecord(EOFRecord)
+}
+
+// addressRecord returns the extended address record for a 64 KiB aligned
+// address base.
+func (e *Encoder) addressRecord(base uint32) *Record {
+	if e.Addressing == SegmentAddressing {
+		base >>= 4
+		return NewRecord(RecordTypeExtSegAddr, 0, []byte{
+			byte(base >> 8),
+			byte(base >> 0),
+		})
+	}
+	base >>= 16
+	return NewRecord(RecordTypeExtLinAddr, 0, []byte{
+		byte(base >> 8),
+		byte(base >> 0),
+	})
+}
+
+// writeRecord writes a single record as a line of text.
+func (e *Encoder) writeRecord(record *Record) error {
+	d, err := record.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	line := hex.EncodeToString(d)
+	if !e.LowerCase {
+		line = strings.ToUpper(line)
+	}
+	eol := "\n"
+	if e.UseCRLF {
+		eol = "\r\n"
+	}
+
+	_, err = fmt.Fprintf(e.w, "%c%s%s", StartCode, line, eol)
+	return err
+}
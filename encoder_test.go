@@ -0,0 +1,152 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncoder(t *testing.T) {
+	var cases = []struct {
+		expectErr bool
+		configure func(e *Encoder)
+		segments  SegmentSlice
+		output    string
+	}{
+		// Defaults match SegmentSlice.Write
+		{
+			configure: func(e *Encoder) {},
+			segments:  SegmentSlice{{0x0010, decodeHex("0102")}},
+			output:    ":020010000102EB\n:00000001FF\n",
+		},
+		// Lower case and CRLF
+		{
+			configure: func(e *Encoder) {
+				e.LowerCase = true
+				e.UseCRLF = true
+			},
+			segments: SegmentSlice{{0x2345, decodeHex("AABB")}},
+			output:   ":02234500aabb31\r\n:00000001ff\r\n",
+		},
+		// Initial address record
+		{
+			configure: func(e *Encoder) {
+				e.InitialAddressRecord = true
+			},
+			segments: SegmentSlice{{0x0010, decodeHex("0102")}},
+			output:   ":020000040000FA\n:020010000102EB\n:00000001FF\n",
+		},
+		// Segment addressing
+		{
+			configure: func(e *Encoder) {
+				e.Addressing = SegmentAddressing
+			},
+			segments: SegmentSlice{{0x00012345, decodeHex("AABB")}},
+			output:   ":020000021000EC\n:02234500AABB31\n:00000001FF\n",
+		},
+		// Segment addressing can't reach past 1 MiB
+		{
+			expectErr: true,
+			configure: func(e *Encoder) {
+				e.Addressing = SegmentAddressing
+			},
+			segments: SegmentSlice{{0x000FFFFF, decodeHex("AABB")}},
+		},
+		// Start address
+		{
+			configure: func(e *Encoder) {
+				e.StartAddress = NewStartSegmentAddress(0x1234, 0x0010)
+			},
+			segments: SegmentSlice{{0x0010, decodeHex("0102")}},
+			output:   ":020010000102EB\n:0400000312340010A3\n:00000001FF\n",
+		},
+		// Invalid record size
+		{
+			expectErr: true,
+			configure: func(e *Encoder) {
+				e.RecordSize = 0
+			},
+		},
+	}
+
+	for i, tc := range cases {
+		t.Logf("Case %d", i)
+
+		buf := &bytes.Buffer{}
+		e := NewEncoder(buf)
+		tc.configure(e)
+		err := e.Encode(tc.segments)
+
+		if tc.expectErr {
+			if err == nil {
+				t.Error("expected error")
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		} else if buf.String() != tc.output {
+			t.Error("data mismatch")
+			t.Errorf("	expected=%q", tc.output)
+			t.Errorf("	  actual=%q", buf.String())
+		}
+	}
+}
+
+func TestEncoderSegmentAddressingRoundTrip(t *testing.T) {
+	segments := SegmentSlice{
+		{0x00000100, decodeHex("214601360121470136007EFE09D21901")},
+		{0x0001FFF8, decodeHex("2146017E17C20001FF5F160021480119")},
+		{0x000F0000, decodeHex("194E79234623965778239EDA3F01B2CA")},
+	}
+
+	buf := &bytes.Buffer{}
+	e := NewEncoder(buf)
+	e.Addressing = SegmentAddressing
+	if err := e.Encode(segments); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := ReadImage(NewScanner(buf))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, seg := range segments {
+		data := make([]byte, len(seg.Data))
+		if _, err := img.ReadAt(data, int64(seg.Address)); err != nil {
+			t.Errorf("unexpected error at 0x%08X: %v", seg.Address, err)
+		} else if !bytes.Equal(data, seg.Data) {
+			t.Errorf("data mismatch at 0x%08X: expected=%X, actual=%X", seg.Address, seg.Data, data)
+		}
+	}
+}
+
+func TestEncoderFlatAddressing(t *testing.T) {
+	segments := SegmentSlice{{0x0001FFFE, decodeHex("01020304")}}
+
+	buf := &bytes.Buffer{}
+	e := NewEncoder(buf)
+	e.Addressing = SegmentAddressing
+	e.FlatAddressing = true
+	if err := e.Encode(segments); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := ":020000021000EC\n:04FFFE0001020304F5\n:00000001FF\n"
+	if buf.String() != exp {
+		t.Errorf("output mismatch: expected=%q, actual=%q", exp, buf.String())
+	}
+
+	// Only a flat reader gets the data back where it was
+	s := NewScanner(bytes.NewReader(buf.Bytes()))
+	s.SetMode(ScanFlatAddressing)
+	img, err := ReadImage(s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ranges := img.Ranges(); len(ranges) != 1 || ranges[0] != (Range{0x0001FFFE, 0x00020001}) {
+		t.Errorf("unexpected ranges %v", ranges)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Causes of a ParseError, for use with errors.Is.
+var (
+	ErrLineTooLong       = errors.New("line too long")
+	ErrStartCode         = errors.New("invalid start code")
+	ErrHexDigit          = errors.New("invalid hex digit")
+	ErrRecordLength      = errors.New("invalid record length")
+	ErrInvalidRecordType = errors.New("invalid record type")
+	ErrChecksum          = errors.New("checksum mismatch")
+	ErrMissingEOF        = errors.New("missing EOF record")
+	ErrAfterEOF          = errors.New("unexpected record after EOF record")
+	ErrSegmentBoundary   = errors.New("record straddles a 64 KiB segment boundary")
+)
+
+// ParseError is a problem found on a line of the input.
+type ParseError struct {
+	// Line is the 1-based line number.
+	Line int
+	// Column is the 1-based column of the problem or 0 if it concerns the
+	// line as a whole.
+	Column int
+	// Text is the text of the line.
+	Text string
+	// Err is the cause of the problem. It matches one of the Err variables
+	// with errors.Is.
+	Err error
+}
+
+func (err *ParseError) Error() string {
+	if err.Column == 0 {
+		return fmt.Sprintf("line %d: %v", err.Line, err.Err)
+	}
+	return fmt.Sprintf("line %d, column %d: %v", err.Line, err.Column, err.Err)
+}
+
+func (err *ParseError) Unwrap() error {
+	return err.Err
+}
+
+// recordLengthError is returned when the length of a record doesn't match its
+// byte count or record type.
+type recordLengthError string
+
+func (err recordLengthError) Error() string {
+	return string(err)
+}
+
+func (err recordLengthError) Is(target error) bool {
+	return target == ErrRecordLength
+}
+
+// hexDigitError is returned when a line contains a character that isn't a hex
+// digit.
+type hexDigitError struct {
+	index int
+	char  byte
+}
+
+func (err hexDigitError) Error() string {
+	return fmt.Sprintf("invalid hex digit %q", err.char)
+}
+
+func (err hexDigitError) Is(target error) bool {
+	return target == ErrHexDigit
+}
+
+// decodeHexDigits decodes src, reporting the index of the first invalid
+// character.
+func decodeHexDigits(src []byte) ([]byte, error) {
+	for i, c := range src {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return nil, hexDigitError{i, c}
+		}
+	}
+	if len(src)%2 != 0 {
+		return nil, recordLengthError("odd number of hex digits")
+	}
+
+	dst := make([]byte, len(src)/2)
+	for i := range dst {
+		dst[i] = hexValue(src[2*i])<<4 | hexValue(src[2*i+1])
+	}
+	return dst, nil
+}
+
+func hexValue(c byte) byte {
+	switch {
+	case c >= 'a':
+		return c - 'a' + 10
+	case c >= 'A':
+		return c - 'A' + 10
+	}
+	return c - '0'
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	var cases = []struct {
+		input  string
+		cause  error
+		line   int
+		column int
+	}{
+		{":020000000102FB\n:020010000304E8\n:00000001FF\n", ErrChecksum, 2, 14},
+		{":020000000102FB\n\n020010000304E7\n:00000001FF\n", ErrStartCode, 3, 1},
+		{":020000000102FB\n:02001000030XE7\n:00000001FF\n", ErrHexDigit, 2, 13},
+		{":020000000102FB\n:0100000600F9\n:00000001FF\n", ErrInvalidRecordType, 2, 8},
+		{":020000000102FB\n:0300000001020304F2\n:00000001FF\n", ErrRecordLength, 2, 2},
+		{":020000000102FB\n", ErrMissingEOF, 1, 0},
+	}
+
+	for i, tc := range cases {
+		t.Logf("Case %d", i)
+
+		s := NewScanner(strings.NewReader(tc.input))
+		for s.Scan() {
+		}
+
+		var perr *ParseError
+		if !errors.As(s.Err(), &perr) {
+			t.Errorf("expected parse error but got %v", s.Err())
+			continue
+		}
+		if !errors.Is(perr, tc.cause) {
+			t.Errorf("cause mismatch: expected=%v, actual=%v", tc.cause, perr.Err)
+		}
+		if perr.Line != tc.line {
+			t.Errorf("line mismatch: expected=%d, actual=%d", tc.line, perr.Line)
+		}
+		if perr.Column != tc.column {
+			t.Errorf("column mismatch: expected=%d, actual=%d", tc.column, perr.Column)
+		}
+		if tc.column != 0 && perr.Text != strings.Split(tc.input, "\n")[tc.line-1] {
+			t.Errorf("text mismatch: actual=%q", perr.Text)
+		}
+	}
+}
+
+func TestIsErrorHelpersWrapped(t *testing.T) {
+	var (
+		checksum   = fmt.Errorf("loading: %w", &ParseError{Line: 1, Err: checksumError{0x01, 0x02}})
+		recordType = fmt.Errorf("loading: %w", &ParseError{Line: 1, Err: invalidRecordTypeError(0x06)})
+	)
+
+	if !IsChecksumError(checksum) {
+		t.Error("expected IsChecksumError to see through wrapping")
+	}
+	if IsChecksumError(recordType) {
+		t.Error("unexpected checksum error")
+	}
+	if !IsInvalidRecordTypeError(recordType) {
+		t.Error("expected IsInvalidRecordTypeError to see through wrapping")
+	}
+
+	var (
+		gap       = fmt.Errorf("reading: %w", gapError(0x1000))
+		overlap   = fmt.Errorf("merging: %w", &OverlapError{Range: Range{0x1000, 0x1001}})
+		truncated = fmt.Errorf("flattening: %w", &truncatedError{Range{0, 0xFF}, []Range{{0x100, 0x101}}})
+	)
+	if !IsGapError(gap) || IsGapError(overlap) {
+		t.Error("expected IsGapError to see through wrapping")
+	}
+	if !IsOverlapError(overlap) || IsOverlapError(truncated) {
+		t.Error("expected IsOverlapError to see through wrapping")
+	}
+	if !IsTruncatedError(truncated) || IsTruncatedError(gap) {
+		t.Error("expected IsTruncatedError to see through wrapping")
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DefaultFill is the value of erased flash memory, used to fill gaps when no
+// other fill is given.
+const DefaultFill = 0xFF
+
+// Bounds returns the range from the lowest to the highest address holding
+// data. It returns false if the image is empty.
+func (img *Image) Bounds() (r Range, ok bool) {
+	if len(img.segments) == 0 {
+		return
+	}
+	r.Low = img.segments[0].Address
+	r.High = img.segments[len(img.segments)-1].Range().High
+	return r, true
+}
+
+// Flatten returns the contents of the addresses in window as a contiguous
+// buffer. Addresses holding no data are filled with the fill pattern, repeated
+// from the start of the window, or DefaultFill if no pattern is given. The
+// window may extend past the image's data to pad it, for example to the size
+// of the flash memory.
+//
+// If the image holds data outside of the window the buffer is still returned
+// along with an error for which IsTruncatedError returns true.
+func (img *Image) Flatten(window Range, fill ...byte) ([]byte, error) {
+	if window.High < window.Low {
+		return nil, fmt.Errorf("invalid address window %v", window)
+	}
+	if len(fill) == 0 {
+		fill = []byte{DefaultFill}
+	}
+
+	buf := make([]byte, uint64(window.High-window.Low)+1)
+	for i := range buf {
+		buf[i] = fill[i%len(fill)]
+	}
+
+	inside, outside := img.split(window)
+	for _, s := range inside {
+		copy(buf[s.Address-window.Low:], s.Data)
+	}
+
+	if len(outside) > 0 {
+		dropped := make([]Range, len(outside))
+		for i, s := range outside {
+			dropped[i] = s.Range()
+		}
+		return buf, &truncatedError{window, dropped}
+	}
+	return buf, nil
+}
+
+// IsTruncatedError returns true if the given error, or any error it wraps, was
+// caused by data lying outside of the address window given to Image.Flatten.
+func IsTruncatedError(err error) bool {
+	var terr *truncatedError
+	return errors.As(err, &terr)
+}
+
+type truncatedError struct {
+	window  Range
+	dropped []Range
+}
+
+func (err *truncatedError) Error() string {
+	var size uint64
+	for _, r := range err.dropped {
+		size += uint64(r.High-r.Low) + 1
+	}
+	return fmt.Sprintf("%d bytes of data at %v outside of window %v dropped", size, err.dropped, err.window)
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestImageFlatten(t *testing.T) {
+	var cases = []struct {
+		window    Range
+		fill      []byte
+		data      []byte
+		truncated bool
+	}{
+		// The bounds of the data with the default fill
+		{
+			window: Range{0x1000, 0x1007},
+			data:   decodeHex("0102FFFFFF030405"),
+		},
+		// Padding with a pattern repeated from the start of the window
+		{
+			window: Range{0x0FFE, 0x100A},
+			fill:   decodeHex("DEADBEEF"),
+			data:   decodeHex("DEAD0102DEADBE030405BEEFDE"),
+		},
+		// Truncating drops data
+		{
+			window:    Range{0x1001, 0x1005},
+			fill:      decodeHex("00"),
+			data:      decodeHex("0200000003"),
+			truncated: true,
+		},
+	}
+
+	for i, tc := range cases {
+		t.Logf("Case %d", i)
+
+		var img Image
+		img.WriteAt(decodeHex("0102"), 0x1000)
+		img.WriteAt(decodeHex("030405"), 0x1005)
+
+		data, err := img.Flatten(tc.window, tc.fill...)
+		if tc.truncated != IsTruncatedError(err) {
+			t.Errorf("error mismatch: expected truncated=%t, actual=%v", tc.truncated, err)
+		}
+		if !bytes.Equal(data, tc.data) {
+			t.Errorf("data mismatch: expected=%X, actual=%X", tc.data, data)
+		}
+	}
+}
+
+func TestImageBounds(t *testing.T) {
+	var img Image
+	if _, ok := img.Bounds(); ok {
+		t.Errorf("expected empty image to have no bounds")
+	}
+
+	img.WriteAt(decodeHex("0102"), 0x2000)
+	img.WriteAt(decodeHex("03"), 0x1000)
+	if r, ok := img.Bounds(); !ok || r != (Range{0x1000, 0x2001}) {
+		t.Errorf("bounds mismatch: expected=0x00001000-0x00002001, actual=%v", r)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Format is a file format that images can be read from.
+type Format int
+
+const (
+	FormatIntelHex Format = iota
+	FormatSRecord
+	FormatBinary
+)
+
+var formatNames = []string{
+	FormatIntelHex: "hex",
+	FormatSRecord:  "srec",
+	FormatBinary:   "bin",
+}
+
+func (f Format) String() string {
+	if f < 0 || int(f) >= len(formatNames) {
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+	return formatNames[f]
+}
+
+// ParseFormat returns the format with the given name as returned by
+// Format.String.
+func ParseFormat(name string) (Format, error) {
+	for f, n := range formatNames {
+		if n == name {
+			return Format(f), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown format %q", name)
+}
+
+// formatExtensions maps file extensions to file formats.
+var formatExtensions = map[string]Format{
+	".hex":  FormatIntelHex,
+	".ihex": FormatIntelHex,
+	".ihx":  FormatIntelHex,
+	".s19":  FormatSRecord,
+	".s28":  FormatSRecord,
+	".s37":  FormatSRecord,
+	".srec": FormatSRecord,
+	".mot":  FormatSRecord,
+	".bin":  FormatBinary,
+}
+
+// DetectFormat returns the format matching the file name's extension,
+// defaulting to Intel HEX.
+func DetectFormat(filename string) Format {
+	if f, ok := formatExtensions[strings.ToLower(filepath.Ext(filename))]; ok {
+		return f
+	}
+	return FormatIntelHex
+}
+
+// ReadFile reads an image from a file in the format matching its extension.
+// Binary files are loaded at address 0.
+func ReadFile(filename string) (*Image, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch DetectFormat(filename) {
+	case FormatSRecord:
+		return ReadImage(NewSRecordScanner(f))
+	case FormatBinary:
+		return ReadBinary(f, 0)
+	default:
+		return ReadImage(NewScanner(f))
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import "testing"
+
+func TestDetectFormat(t *testing.T) {
+	var cases = []struct {
+		filename string
+		format   Format
+	}{
+		{"app.hex", FormatIntelHex},
+		{"APP.IHX", FormatIntelHex},
+		{"app.s19", FormatSRecord},
+		{"dir.d/app.S37", FormatSRecord},
+		{"blob.bin", FormatBinary},
+		{"firmware", FormatIntelHex},
+	}
+
+	for i, tc := range cases {
+		t.Logf("Case %d", i)
+		if f := DetectFormat(tc.filename); f != tc.format {
+			t.Errorf("format mismatch: expected=%v, actual=%v", tc.format, f)
+		}
+		if f, err := ParseFormat(tc.format.String()); err != nil || f != tc.format {
+			t.Errorf("parse mismatch: expected=%v, actual=%v (%v)", tc.format, f, err)
+		}
+	}
+}
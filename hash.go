@@ -0,0 +1,226 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/adler32"
+	"hash/crc32"
+)
+
+// HashAlgorithm is a checksum or digest algorithm that can be computed over an
+// image.
+type HashAlgorithm int
+
+const (
+	// HashCRC16CCITT is CRC-16/CCITT-FALSE: polynomial 0x1021, initial value
+	// 0xFFFF, no reflection and no final XOR.
+	HashCRC16CCITT HashAlgorithm = iota
+	// HashCRC32 is CRC-32 with the IEEE polynomial as used by zlib.
+	HashCRC32
+	// HashCRC32C is CRC-32 with the Castagnoli polynomial.
+	HashCRC32C
+	HashAdler32
+	HashSHA1
+	HashSHA256
+)
+
+var hashAlgorithmNames = []string{
+	HashCRC16CCITT: "crc16-ccitt",
+	HashCRC32:      "crc32",
+	HashCRC32C:     "crc32c",
+	HashAdler32:    "adler32",
+	HashSHA1:       "sha1",
+	HashSHA256:     "sha256",
+}
+
+func (a HashAlgorithm) String() string {
+	if a < 0 || int(a) >= len(hashAlgorithmNames) {
+		return fmt.Sprintf("HashAlgorithm(%d)", int(a))
+	}
+	return hashAlgorithmNames[a]
+}
+
+// ParseHashAlgorithm returns the algorithm with the given name as returned by
+// HashAlgorithm.String.
+func ParseHashAlgorithm(name string) (HashAlgorithm, error) {
+	for a, n := range hashAlgorithmNames {
+		if n == name {
+			return HashAlgorithm(a), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown hash algorithm %q", name)
+}
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// New returns a new hash.Hash computing the algorithm. Sums are big-endian.
+func (a HashAlgorithm) New() hash.Hash {
+	switch a {
+	case HashCRC16CCITT:
+		return NewCRC16CCITT()
+	case HashCRC32:
+		return crc32.NewIEEE()
+	case HashCRC32C:
+		return crc32.New(castagnoliTable)
+	case HashAdler32:
+		return adler32.New()
+	case HashSHA1:
+		return sha1.New()
+	case HashSHA256:
+		return sha256.New()
+	}
+	panic(fmt.Sprintf("unknown hash algorithm %v", a))
+}
+
+// hashChunkSize is the size of the buffer of fill bytes written for gaps.
+const hashChunkSize = 4096
+
+// Hash writes the contents of the addresses in r to h, in ascending order.
+// Addresses holding no data are written as fill.
+func (img *Image) Hash(h hash.Hash, r Range, fill byte) error {
+	if r.High < r.Low {
+		return fmt.Errorf("invalid address range %v", r)
+	}
+
+	var (
+		chunk   []byte
+		next    = uint64(r.Low)
+		writeTo = func(end uint64) {
+			if next >= end {
+				return
+			}
+			if chunk == nil {
+				chunk = make([]byte, hashChunkSize)
+				for i := range chunk {
+					chunk[i] = fill
+				}
+			}
+			for ; end-next > hashChunkSize; next += hashChunkSize {
+				h.Write(chunk)
+			}
+			h.Write(chunk[:end-next])
+			next = end
+		}
+	)
+
+	inside, _ := img.split(r)
+	for _, s := range inside {
+		writeTo(uint64(s.Address))
+		h.Write(s.Data)
+		next = s.end()
+	}
+	writeTo(uint64(r.High) + 1)
+
+	return nil
+}
+
+// Checksum returns the sum of the algorithm computed over the contents of the
+// addresses in r, with the addresses holding no data treated as fill.
+func (img *Image) Checksum(a HashAlgorithm, r Range, fill byte) ([]byte, error) {
+	h := a.New()
+	if err := img.Hash(h, r, fill); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// InjectHash computes h over the contents of the addresses in r, with the
+// addresses holding no data treated as fill, and writes the sum to the image at
+// target in the given byte order, which must be binary.BigEndian or
+// binary.LittleEndian. It returns the sum as written.
+//
+// The sum must not overlap r, and it must not overlap data already in the
+// image or an error for which IsOverlapError returns true is returned. In
+// either case the image is left unchanged.
+func (img *Image) InjectHash(h hash.Hash, r Range, fill byte, target uint32, order binary.ByteOrder) ([]byte, error) {
+	if uint64(target)+uint64(h.Size()) > addressSpaceSize {
+		return nil, fmt.Errorf("sum of %d bytes at 0x%08X extends past the 32-bit address space", h.Size(), target)
+	}
+	dest := Range{target, target + uint32(h.Size()) - 1}
+	if dest.Overlaps(r) {
+		return nil, fmt.Errorf("sum at %v overlaps the hashed range %v", dest, r)
+	}
+	if inside, _ := img.split(dest); len(inside) > 0 {
+		overlap := Range{inside[0].Address, inside[len(inside)-1].Range().High}
+		return nil, &OverlapError{Range: overlap, SecondSource: "the injected sum"}
+	}
+
+	h.Reset()
+	if err := img.Hash(h, r, fill); err != nil {
+		return nil, err
+	}
+	sum := h.Sum(nil)
+	switch order {
+	case binary.BigEndian:
+	case binary.LittleEndian:
+		for i, j := 0, len(sum)-1; i < j; i, j = i+1, j-1 {
+			sum[i], sum[j] = sum[j], sum[i]
+		}
+	default:
+		return nil, fmt.Errorf("unsupported byte order %v", order)
+	}
+
+	if _, err := img.WriteAt(sum, int64(target)); err != nil {
+		return nil, err
+	}
+	return sum, nil
+}
+
+// crc16CCITT implements CRC-16/CCITT-FALSE.
+type crc16CCITT uint16
+
+var crc16CCITTTable = makeCRC16Table(0x1021)
+
+func makeCRC16Table(poly uint16) *[256]uint16 {
+	table := new([256]uint16)
+	for i := range table {
+		crc := uint16(i) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ poly
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}
+
+// NewCRC16CCITT returns a new hash.Hash computing CRC-16/CCITT-FALSE. Its sum
+// is big-endian.
+func NewCRC16CCITT() hash.Hash {
+	crc := crc16CCITT(0xFFFF)
+	return &crc
+}
+
+func (crc *crc16CCITT) Write(p []byte) (n int, err error) {
+	c := uint16(*crc)
+	for _, b := range p {
+		c = c<<8 ^ crc16CCITTTable[byte(c>>8)^b]
+	}
+	*crc = crc16CCITT(c)
+	return len(p), nil
+}
+
+func (crc *crc16CCITT) Sum(b []byte) []byte {
+	return append(b, byte(*crc>>8), byte(*crc))
+}
+
+func (crc *crc16CCITT) Reset() {
+	*crc = 0xFFFF
+}
+
+func (crc *crc16CCITT) Size() int {
+	return 2
+}
+
+func (crc *crc16CCITT) BlockSize() int {
+	return 1
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+)
+
+func TestHashAlgorithms(t *testing.T) {
+	// Check values of the standard test input "123456789"
+	var cases = []struct {
+		algorithm HashAlgorithm
+		sum       []byte
+	}{
+		{HashCRC16CCITT, decodeHex("29B1")},
+		{HashCRC32, decodeHex("CBF43926")},
+		{HashCRC32C, decodeHex("E3069283")},
+		{HashAdler32, decodeHex("091E01DE")},
+		{HashSHA1, decodeHex("F7C3BC1D808E04732ADF679965CCC34CA7AE3441")},
+		{HashSHA256, decodeHex("15E2B0D3C33891EBB0F1EF609EC419420C20E320CE94C65FBC8C3312448EB225")},
+	}
+
+	var img Image
+	img.WriteAt([]byte("123456789"), 0x1000)
+
+	for i, tc := range cases {
+		t.Logf("Case %d", i)
+
+		a, err := ParseHashAlgorithm(tc.algorithm.String())
+		if err != nil || a != tc.algorithm {
+			t.Errorf("parse mismatch: expected=%v, actual=%v (%v)", tc.algorithm, a, err)
+		}
+
+		sum, err := img.Checksum(tc.algorithm, Range{0x1000, 0x1008}, 0xFF)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		} else if !bytes.Equal(sum, tc.sum) {
+			t.Errorf("sum mismatch: expected=%X, actual=%X", tc.sum, sum)
+		}
+	}
+}
+
+func TestImageHashFill(t *testing.T) {
+	var img Image
+	img.WriteAt(decodeHex("0102"), 0x0002)
+	img.WriteAt(decodeHex("03"), 0x2000)
+
+	// Gaps larger than the fill buffer are hashed as fill too
+	r := Range{0x0000, 0x2001}
+	data, _ := img.Flatten(r, 0x5A)
+
+	h := sha256.New()
+	if err := img.Hash(h, r, 0x5A); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := sha256.Sum256(data); !bytes.Equal(h.Sum(nil), expected[:]) {
+		t.Errorf("sum mismatch: expected=%X, actual=%X", expected, h.Sum(nil))
+	}
+}
+
+func TestImageInjectHash(t *testing.T) {
+	var cases = []struct {
+		target uint32
+		order  binary.ByteOrder
+		sum    []byte
+		fail   bool
+	}{
+		{0x10FC, binary.LittleEndian, decodeHex("2639F4CB"), false},
+		{0x10FC, binary.BigEndian, decodeHex("CBF43926"), false},
+		// Overlapping the hashed range
+		{0x1006, binary.BigEndian, nil, true},
+		// Overlapping data outside of the hashed range
+		{0x11FE, binary.BigEndian, nil, true},
+		// Past the end of the address space
+		{0xFFFFFFFE, binary.BigEndian, nil, true},
+	}
+
+	for i, tc := range cases {
+		t.Logf("Case %d", i)
+
+		var img Image
+		img.WriteAt([]byte("123456789"), 0x1000)
+		img.WriteAt(decodeHex("AABB"), 0x1200)
+
+		sum, err := img.InjectHash(HashCRC32.New(), Range{0x1000, 0x1008}, 0xFF, tc.target, tc.order)
+		if tc.fail {
+			if err == nil {
+				t.Errorf("expected error")
+			}
+			if size := img.Size(); size != 11 {
+				t.Errorf("image changed by failed injection, size=%d", size)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+
+		data := make([]byte, 4)
+		img.ReadAt(data, int64(tc.target))
+		if !bytes.Equal(sum, tc.sum) || !bytes.Equal(data, tc.sum) {
+			t.Errorf("sum mismatch: expected=%X, actual=%X written=%X", tc.sum, sum, data)
+		}
+	}
+}
@@ -0,0 +1,427 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// Range is an inclusive range of absolute addresses.
+type Range struct {
+	Low  uint32
+	High uint32
+}
+
+// Size returns the number of addresses in the range. The full 32-bit address
+// space wraps around to 0.
+func (r Range) Size() uint32 {
+	return r.High - r.Low + 1
+}
+
+// Contains returns true if address is within the range.
+func (r Range) Contains(address uint32) bool {
+	return address >= r.Low && address <= r.High
+}
+
+// Overlaps returns true if the two ranges share at least one address.
+func (r Range) Overlaps(o Range) bool {
+	return r.Low <= o.High && o.Low <= r.High
+}
+
+func (r Range) String() string {
+	return fmt.Sprintf("0x%08X-0x%08X", r.Low, r.High)
+}
+
+// MarshalJSON encodes the range with its addresses as hex strings and its
+// size.
+func (r Range) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Low  string `json:"low"`
+		High string `json:"high"`
+		Size uint64 `json:"size"`
+	}{
+		Low:  fmt.Sprintf("0x%08X", r.Low),
+		High: fmt.Sprintf("0x%08X", r.High),
+		Size: uint64(r.High-r.Low) + 1,
+	})
+}
+
+// end returns the address just past the segment's last byte.
+func (s *Segment) end() uint64 {
+	return uint64(s.Address) + uint64(len(s.Data))
+}
+
+// Range returns the addresses occupied by the segment. It must not be called
+// on an empty segment.
+func (s *Segment) Range() Range {
+	return Range{s.Address, uint32(s.end() - 1)}
+}
+
+// Image is a sparse 32-bit address space. Data written to it is kept as a
+// sorted list of segments where adjacent and overlapping writes are coalesced.
+// The zero value is an empty image ready to use.
+type Image struct {
+	segments SegmentSlice
+
+	// Start is the image's start address or nil if it has none.
+	Start *StartAddress
+}
+
+// SegmentScanner is implemented by the scanners of the supported file formats,
+// Scanner and SRecordScanner.
+type SegmentScanner interface {
+	Scan() bool
+	Segment() Segment
+	Line() int
+	Err() error
+	StartAddress() *StartAddress
+}
+
+// ReadImage reads all segments and the start address from s into a new image.
+// Data records that overlap earlier ones cause an error for which
+// IsOverlapError returns true.
+func ReadImage(s SegmentScanner) (*Image, error) {
+	return ReadImageWithPolicy(s, OverlapReject)
+}
+
+// ReadImageWithPolicy is like ReadImage but resolves overlapping data records
+// according to policy.
+func ReadImageWithPolicy(s SegmentScanner, policy OverlapPolicy) (*Image, error) {
+	var (
+		img    = new(Image)
+		loader = &overlapLoader{img: img, policy: policy}
+	)
+	for s.Scan() {
+		if err := loader.load(s.Segment(), origin{line: s.Line()}); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	img.Start = s.StartAddress()
+	return img, nil
+}
+
+// ReadImageFile reads an Intel HEX file into a new image.
+func ReadImageFile(filename string) (*Image, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadImage(NewScanner(f))
+}
+
+const addressSpaceSize = 1 << 32
+
+// WriteAt writes p at the absolute address off, overwriting any data already
+// there. It implements io.WriterAt.
+func (img *Image) WriteAt(p []byte, off int64) (n int, err error) {
+	if off < 0 || off+int64(len(p)) > addressSpaceSize {
+		return 0, fmt.Errorf("write of %d bytes at 0x%X is outside the 32-bit address space", len(p), off)
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	var (
+		low  = uint64(off)
+		high = low + uint64(len(p))
+	)
+
+	// Find the segments that overlap or touch the written range
+	i := sort.Search(len(img.segments), func(i int) bool {
+		return img.segments[i].end() >= low
+	})
+	j := i
+	for j < len(img.segments) && uint64(img.segments[j].Address) <= high {
+		j++
+	}
+
+	if i < j {
+		if first := uint64(img.segments[i].Address); first < low {
+			low = first
+		}
+		if last := img.segments[j-1].end(); last > high {
+			high = last
+		}
+	}
+
+	// Coalesce them into a single segment with p written over the top
+	seg := &Segment{
+		Address: uint32(low),
+		Data:    make([]byte, high-low),
+	}
+	for _, s := range img.segments[i:j] {
+		copy(seg.Data[uint64(s.Address)-low:], s.Data)
+	}
+	copy(seg.Data[uint64(off)-low:], p)
+
+	segments := make(SegmentSlice, 0, len(img.segments)-(j-i)+1)
+	segments = append(segments, img.segments[:i]...)
+	segments = append(segments, seg)
+	segments = append(segments, img.segments[j:]...)
+	img.segments = segments
+
+	return len(p), nil
+}
+
+// ReadAt reads len(p) bytes from the absolute address off. It implements
+// io.ReaderAt. If the read reaches an address that holds no data it stops
+// there and returns an error for which IsGapError returns true.
+func (img *Image) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 || off+int64(len(p)) > addressSpaceSize {
+		return 0, fmt.Errorf("read of %d bytes at 0x%X is outside the 32-bit address space", len(p), off)
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	i := sort.Search(len(img.segments), func(i int) bool {
+		return img.segments[i].end() > uint64(off)
+	})
+	if i == len(img.segments) || int64(img.segments[i].Address) > off {
+		return 0, gapError(off)
+	}
+
+	s := img.segments[i]
+	n = copy(p, s.Data[off-int64(s.Address):])
+	if n < len(p) {
+		return n, gapError(off + int64(n))
+	}
+	return n, nil
+}
+
+// IsGapError returns true if the given error, or any error it wraps, was caused
+// by reading an address that holds no data.
+func IsGapError(err error) bool {
+	var gerr gapError
+	return errors.As(err, &gerr)
+}
+
+type gapError uint32
+
+func (err gapError) Error() string {
+	return fmt.Sprintf("no data at address 0x%08X", uint32(err))
+}
+
+// Segments returns a copy of the image's data as sorted, coalesced segments,
+// ready to be written with SegmentSlice.Write.
+func (img *Image) Segments() SegmentSlice {
+	segments := make(SegmentSlice, len(img.segments))
+	for i, s := range img.segments {
+		segments[i] = &Segment{
+			Address: s.Address,
+			Data:    make([]byte, len(s.Data)),
+		}
+		copy(segments[i].Data, s.Data)
+	}
+	return segments
+}
+
+// Ranges returns the address ranges that hold data, in ascending order.
+func (img *Image) Ranges() []Range {
+	ranges := make([]Range, len(img.segments))
+	for i, s := range img.segments {
+		ranges[i] = s.Range()
+	}
+	return ranges
+}
+
+// Gaps returns the address ranges between the lowest and highest occupied
+// addresses that hold no data, in ascending order.
+func (img *Image) Gaps() []Range {
+	gaps := make([]Range, 0)
+	for i := 1; i < len(img.segments); i++ {
+		gaps = append(gaps, Range{
+			Low:  uint32(img.segments[i-1].end()),
+			High: img.segments[i].Address - 1,
+		})
+	}
+	return gaps
+}
+
+// holes returns the ranges within r that hold no data, in ascending order.
+func (img *Image) holes(r Range) []Range {
+	var (
+		holes = make([]Range, 0)
+		next  = uint64(r.Low)
+	)
+	for _, s := range img.segments {
+		if len(s.Data) == 0 || !s.Range().Overlaps(r) {
+			continue
+		}
+		if uint64(s.Address) > next {
+			holes = append(holes, Range{uint32(next), s.Address - 1})
+		}
+		next = s.end()
+	}
+	if next <= uint64(r.High) {
+		holes = append(holes, Range{uint32(next), r.High})
+	}
+	return holes
+}
+
+// Fill writes value to every address in r that holds no data. It returns an
+// error if r covers the whole 32-bit address space, whose size doesn't fit in
+// a uint32.
+func (img *Image) Fill(r Range, value byte) error {
+	if r.High < r.Low {
+		return fmt.Errorf("invalid address range %v", r)
+	}
+	if r.Size() == 0 {
+		return fmt.Errorf("address range %v is too large to fill", r)
+	}
+	for _, h := range img.holes(r) {
+		data := make([]byte, h.Size())
+		for i := range data {
+			data[i] = value
+		}
+		if _, err := img.WriteAt(data, int64(h.Low)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Crop removes all data outside of the given ranges, splitting segments at the
+// range edges.
+func (img *Image) Crop(ranges ...Range) {
+	segments := make(SegmentSlice, 0, len(img.segments))
+	for _, r := range normalizeRanges(ranges) {
+		inside, _ := img.split(r)
+		segments = append(segments, inside...)
+	}
+	img.segments = segments
+}
+
+// Exclude removes all data inside the given ranges, splitting segments at the
+// range edges. Inverted ranges are ignored, as by Crop.
+func (img *Image) Exclude(ranges ...Range) {
+	for _, r := range normalizeRanges(ranges) {
+		_, img.segments = img.split(r)
+	}
+}
+
+// Extract returns a new image holding a copy of the data inside the given
+// ranges and the start address, leaving img unchanged.
+func (img *Image) Extract(ranges ...Range) *Image {
+	extracted := &Image{segments: img.Segments()}
+	extracted.Crop(ranges...)
+	if img.Start != nil {
+		start := *img.Start
+		extracted.Start = &start
+	}
+	return extracted
+}
+
+// split returns the parts of the image's data inside and outside of r, in
+// ascending order. The returned segments share their data with the image.
+func (img *Image) split(r Range) (inside, outside SegmentSlice) {
+	inside = make(SegmentSlice, 0)
+	outside = make(SegmentSlice, 0, len(img.segments))
+	for _, s := range img.segments {
+		sr := s.Range()
+		if !sr.Overlaps(r) {
+			outside = append(outside, s)
+			continue
+		}
+
+		if sr.Low < r.Low {
+			outside = append(outside, &Segment{s.Address, s.Data[:r.Low-s.Address]})
+		}
+		var (
+			low  = maxAddress(sr.Low, r.Low)
+			high = minAddress(sr.High, r.High)
+		)
+		inside = append(inside, &Segment{low, s.Data[low-s.Address : high-s.Address+1]})
+		if sr.High > r.High {
+			outside = append(outside, &Segment{r.High + 1, s.Data[r.High+1-s.Address:]})
+		}
+	}
+	return
+}
+
+// normalizeRanges returns the ranges sorted with overlapping and adjacent
+// ranges joined.
+func normalizeRanges(ranges []Range) []Range {
+	sorted := make([]Range, 0, len(ranges))
+	for _, r := range ranges {
+		if r.High >= r.Low {
+			sorted = append(sorted, r)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Low < sorted[j].Low
+	})
+
+	normalized := make([]Range, 0, len(sorted))
+	for _, r := range sorted {
+		if n := len(normalized); n > 0 && uint64(r.Low) <= uint64(normalized[n-1].High)+1 {
+			normalized[n-1].High = maxAddress(normalized[n-1].High, r.High)
+			continue
+		}
+		normalized = append(normalized, r)
+	}
+	return normalized
+}
+
+// Merge writes the data of other into img, resolving overlapping data
+// according to policy. Differing start addresses are resolved the same way.
+func (img *Image) Merge(other *Image, policy OverlapPolicy) error {
+	loader := &overlapLoader{img: img, policy: policy}
+	for _, r := range img.Ranges() {
+		loader.owners = append(loader.owners, owner{Range: r})
+	}
+	for _, s := range other.Segments() {
+		if err := loader.load(*s, origin{}); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case other.Start == nil:
+	case img.Start == nil, policy == OverlapLastWins:
+		start := *other.Start
+		img.Start = &start
+	case *img.Start != *other.Start && policy != OverlapFirstWins:
+		return fmt.Errorf("start address %v conflicts with %v", other.Start, img.Start)
+	}
+	return nil
+}
+
+// Size returns the number of bytes of data held by the image.
+func (img *Image) Size() uint64 {
+	var size uint64
+	for _, s := range img.segments {
+		size += uint64(len(s.Data))
+	}
+	return size
+}
+
+// Write writes the image in Intel HEX format including its start address.
+func (img *Image) Write(w io.Writer) error {
+	return img.Encode(NewEncoder(w))
+}
+
+// Encode writes the image with e's options but the image's start address.
+func (img *Image) Encode(e *Encoder) error {
+	enc := *e
+	enc.StartAddress = img.Start
+	return enc.Encode(img.Segments())
+}
+
+// EncodeSRecords writes the image in Motorola S-record format with e's
+// options but the image's start address.
+func (img *Image) EncodeSRecords(e *SRecordEncoder) error {
+	enc := *e
+	enc.StartAddress = img.Start
+	return enc.Encode(img.Segments())
+}
@@ -0,0 +1,295 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestImageWriteAt(t *testing.T) {
+	var cases = []struct {
+		writes []Segment
+		ranges []Range
+	}{
+		// Disjoint writes stay apart and are sorted
+		{
+			writes: []Segment{
+				{0x0200, decodeHex("0102")},
+				{0x0100, decodeHex("0304")},
+			},
+			ranges: []Range{{0x0100, 0x0101}, {0x0200, 0x0201}},
+		},
+		// Adjacent writes are coalesced
+		{
+			writes: []Segment{
+				{0x0100, decodeHex("0102")},
+				{0x0102, decodeHex("0304")},
+				{0x00FE, decodeHex("0506")},
+			},
+			ranges: []Range{{0x00FE, 0x0103}},
+		},
+		// A write bridging two segments joins them
+		{
+			writes: []Segment{
+				{0x0100, decodeHex("0102")},
+				{0x0104, decodeHex("0304")},
+				{0x0101, decodeHex("AABBCC")},
+			},
+			ranges: []Range{{0x0100, 0x0105}},
+		},
+		// The top of the address space is usable
+		{
+			writes: []Segment{
+				{0xFFFFFFFE, decodeHex("0102")},
+			},
+			ranges: []Range{{0xFFFFFFFE, 0xFFFFFFFF}},
+		},
+	}
+
+	for i, tc := range cases {
+		t.Logf("Case %d", i)
+
+		var img Image
+		for _, w := range tc.writes {
+			if _, err := img.WriteAt(w.Data, int64(w.Address)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		ranges := img.Ranges()
+		if len(ranges) != len(tc.ranges) {
+			t.Errorf("range count mismatch: expected=%v, actual=%v", tc.ranges, ranges)
+			continue
+		}
+		for j := range ranges {
+			if ranges[j] != tc.ranges[j] {
+				t.Errorf("    [range %d] mismatch: expected=%v, actual=%v", j, tc.ranges[j], ranges[j])
+			}
+		}
+	}
+}
+
+func TestImageWriteAtOutOfRange(t *testing.T) {
+	var img Image
+	if _, err := img.WriteAt([]byte{1, 2}, 0xFFFFFFFF); err == nil {
+		t.Error("expected error writing past the end of the address space")
+	}
+	if _, err := img.WriteAt([]byte{1}, -1); err == nil {
+		t.Error("expected error writing at a negative address")
+	}
+}
+
+func TestImageReadAt(t *testing.T) {
+	var img Image
+	img.WriteAt(decodeHex("01020304"), 0x1000)
+	img.WriteAt(decodeHex("AABB"), 0x1002)
+	img.WriteAt(decodeHex("0506"), 0x2000)
+
+	buf := make([]byte, 4)
+	n, err := img.ReadAt(buf, 0x1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(buf[:n], decodeHex("0102AABB")) {
+		t.Errorf("data mismatch: expected=0102AABB, actual=%X", buf[:n])
+	}
+
+	n, err = img.ReadAt(buf, 0x1002)
+	if !IsGapError(err) {
+		t.Errorf("expected gap error but got %v", err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 bytes read but got %d", n)
+	}
+
+	if _, err = img.ReadAt(buf, 0x0FFF); !IsGapError(err) {
+		t.Errorf("expected gap error but got %v", err)
+	}
+
+	gaps := img.Gaps()
+	if len(gaps) != 1 || gaps[0] != (Range{0x1004, 0x1FFF}) {
+		t.Errorf("gap mismatch: expected=[0x00001004-0x00001FFF], actual=%v", gaps)
+	}
+	if img.Size() != 6 {
+		t.Errorf("expected size 6 but got %d", img.Size())
+	}
+}
+
+func TestReadImage(t *testing.T) {
+	r := strings.NewReader(`
+:10010000214601360121470136007EFE09D2190140
+:100110002146017E17C20001FF5F16002148011928
+:020000040001F9
+:10012000194E79234623965778239EDA3F01B2CAA7
+:04000005000000CD2A
+:00000001FF`)
+
+	img, err := ReadImage(NewScanner(r))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	segments := img.Segments()
+	if len(segments) != 2 {
+		t.Fatalf("expected 2 segments but got %d", len(segments))
+	}
+	if segments[0].Address != 0x0100 || len(segments[0].Data) != 32 {
+		t.Errorf("unexpected first segment 0x%08X (%d bytes)", segments[0].Address, len(segments[0].Data))
+	}
+	if segments[1].Address != 0x00010120 || len(segments[1].Data) != 16 {
+		t.Errorf("unexpected second segment 0x%08X (%d bytes)", segments[1].Address, len(segments[1].Data))
+	}
+	if img.Start == nil || img.Start.Address() != 0xCD {
+		t.Errorf("unexpected start address %v", img.Start)
+	}
+}
+
+func TestImageFill(t *testing.T) {
+	var img Image
+	img.WriteAt(decodeHex("0102"), 0x1002)
+	img.WriteAt(decodeHex("0304"), 0x1006)
+
+	if err := img.Fill(Range{0x1000, 0x1009}, 0xFF); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data := make([]byte, 10)
+	if _, err := img.ReadAt(data, 0x1000); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(data, decodeHex("FFFF0102FFFF0304FFFF")) {
+		t.Errorf("data mismatch: expected=FFFF0102FFFF0304FFFF, actual=%X", data)
+	}
+}
+
+func TestImageFillInvalid(t *testing.T) {
+	var cases = []Range{
+		{0x1000, 0x0FFF},
+		{0, 0xFFFFFFFF},
+	}
+	for i, r := range cases {
+		t.Logf("Case %d", i)
+		var img Image
+		if err := img.Fill(r, 0xFF); err == nil {
+			t.Errorf("expected error filling %v", r)
+		}
+		if img.Size() != 0 {
+			t.Errorf("size mismatch: expected=0, actual=%d", img.Size())
+		}
+	}
+}
+
+func TestImageCrop(t *testing.T) {
+	var img Image
+	img.WriteAt(decodeHex("01020304"), 0x1000)
+	img.WriteAt(decodeHex("05060708"), 0x2000)
+	img.WriteAt(decodeHex("090A0B0C"), 0x3000)
+
+	img.Crop(Range{0x1002, 0x2001})
+
+	ranges := img.Ranges()
+	if len(ranges) != 2 || ranges[0] != (Range{0x1002, 0x1003}) || ranges[1] != (Range{0x2000, 0x2001}) {
+		t.Errorf("range mismatch: expected=[0x00001002-0x00001003 0x00002000-0x00002001], actual=%v", ranges)
+	}
+}
+
+func TestImageCropExclude(t *testing.T) {
+	var cases = []struct {
+		ranges  []Range
+		crop    []Range
+		exclude []Range
+	}{
+		// Several ranges, out of order and overlapping
+		{
+			ranges:  []Range{{0x2001, 0x2002}, {0x1000, 0x1001}, {0x2002, 0x2005}},
+			crop:    []Range{{0x1000, 0x1001}, {0x2001, 0x2005}},
+			exclude: []Range{{0x1002, 0x1003}, {0x2000, 0x2000}, {0x2006, 0x2007}, {0x3000, 0x3003}},
+		},
+		// A range inside a single segment splits it
+		{
+			ranges:  []Range{{0x3001, 0x3002}},
+			crop:    []Range{{0x3001, 0x3002}},
+			exclude: []Range{{0x1000, 0x1003}, {0x2000, 0x2007}, {0x3000, 0x3000}, {0x3003, 0x3003}},
+		},
+		// An inverted range is ignored
+		{
+			ranges:  []Range{{0x2005, 0x2002}, {0x3001, 0x3002}},
+			crop:    []Range{{0x3001, 0x3002}},
+			exclude: []Range{{0x1000, 0x1003}, {0x2000, 0x2007}, {0x3000, 0x3000}, {0x3003, 0x3003}},
+		},
+		// No ranges
+		{
+			crop:    []Range{},
+			exclude: []Range{{0x1000, 0x1003}, {0x2000, 0x2007}, {0x3000, 0x3003}},
+		},
+	}
+
+	for i, tc := range cases {
+		t.Logf("Case %d", i)
+
+		var img Image
+		img.WriteAt(decodeHex("01020304"), 0x1000)
+		img.WriteAt(decodeHex("0506070809101112"), 0x2000)
+		img.WriteAt(decodeHex("13141516"), 0x3000)
+		img.Start = NewStartLinearAddress(0x1000)
+
+		extracted := img.Extract(tc.ranges...)
+		if ranges := extracted.Ranges(); !equalRanges(ranges, tc.crop) {
+			t.Errorf("extract mismatch: expected=%v, actual=%v", tc.crop, ranges)
+		}
+		if extracted.Start == nil || *extracted.Start != *img.Start {
+			t.Errorf("expected start address %v but got %v", img.Start, extracted.Start)
+		}
+
+		excluded := img.Extract(Range{0, 0xFFFFFFFF})
+		excluded.Exclude(tc.ranges...)
+		if ranges := excluded.Ranges(); !equalRanges(ranges, tc.exclude) {
+			t.Errorf("exclude mismatch: expected=%v, actual=%v", tc.exclude, ranges)
+		}
+
+		img.Crop(tc.ranges...)
+		if ranges := img.Ranges(); !equalRanges(ranges, tc.crop) {
+			t.Errorf("crop mismatch: expected=%v, actual=%v", tc.crop, ranges)
+		}
+	}
+}
+
+func equalRanges(a, b []Range) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestImageMerge(t *testing.T) {
+	var a, b Image
+	a.WriteAt(decodeHex("01020304"), 0x1000)
+	b.WriteAt(decodeHex("AABB"), 0x1003)
+	b.Start = NewStartLinearAddress(0x1000)
+
+	if err := a.Merge(&b, OverlapReject); !IsOverlapError(err) {
+		t.Errorf("expected overlap error but got %v", err)
+	}
+
+	if err := a.Merge(&b, OverlapFirstWins); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data := make([]byte, 5)
+	if _, err := a.ReadAt(data, 0x1000); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(data, decodeHex("01020304BB")) {
+		t.Errorf("data mismatch: expected=01020304BB, actual=%X", data)
+	}
+	if a.Start == nil || *a.Start != *b.Start {
+		t.Errorf("expected start address %v but got %v", b.Start, a.Start)
+	}
+}
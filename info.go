@@ -0,0 +1,159 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// Summary describes the contents of an image and, if it was read from Intel
+// HEX by Info, the records it was read from.
+type Summary struct {
+	// Ranges and Gaps are the address ranges holding and lacking data as
+	// returned by Image.Ranges and Image.Gaps.
+	Ranges []Range `json:"ranges"`
+	Gaps   []Range `json:"gaps"`
+	// Size is the number of bytes of data.
+	Size uint64 `json:"size"`
+	// Bounds is the range from the lowest to the highest address holding
+	// data, nil if the image is empty.
+	Bounds *Range `json:"bounds"`
+	// Start is the start address, nil if there is none.
+	Start *StartAddress `json:"start"`
+	// Records describes the Intel HEX records, nil if the summary was made
+	// from an image.
+	Records *RecordSummary `json:"records,omitempty"`
+}
+
+// RecordSummary describes the records of an Intel HEX file.
+type RecordSummary struct {
+	// Counts is the number of records of each type.
+	Counts RecordCounts `json:"counts"`
+	// MaxLength is the largest number of data bytes in a data record.
+	MaxLength int `json:"max_length"`
+	// Addressing is the kind of address records used: "none", "segment",
+	// "linear" or "mixed".
+	Addressing string `json:"addressing"`
+	// Canonical is true if the file is in the canonical form written by
+	// Normalize.
+	Canonical bool `json:"canonical"`
+}
+
+// RecordCounts are numbers of records indexed by record type.
+type RecordCounts [NumRecordTypes]int
+
+var recordTypeNames = [NumRecordTypes]string{
+	RecordTypeData:         "data",
+	RecordTypeEOF:          "eof",
+	RecordTypeExtSegAddr:   "extended_segment_address",
+	RecordTypeStartSegAddr: "start_segment_address",
+	RecordTypeExtLinAddr:   "extended_linear_address",
+	RecordTypeStartLinAddr: "start_linear_address",
+}
+
+// MarshalJSON encodes the counts as an object keyed by record type name.
+func (c RecordCounts) MarshalJSON() ([]byte, error) {
+	counts := make(map[string]int, len(c))
+	for t, n := range c {
+		counts[recordTypeNames[t]] = n
+	}
+	return json.Marshal(counts)
+}
+
+// Summary returns a summary of the image's contents.
+func (img *Image) Summary() *Summary {
+	s := &Summary{
+		Ranges: img.Ranges(),
+		Gaps:   img.Gaps(),
+		Size:   img.Size(),
+	}
+	if r, ok := img.Bounds(); ok {
+		s.Bounds = &r
+	}
+	if img.Start != nil {
+		start := *img.Start
+		s.Start = &start
+	}
+	return s
+}
+
+// InfoOptions control how InfoWithOptions reads its input. The zero value reads
+// it like Info.
+type InfoOptions struct {
+	// Mode is the scan mode.
+	Mode ScanMode
+	// Policy resolves overlapping data records.
+	Policy OverlapPolicy
+	// MaxLineLength is the longest line accepted, DefaultMaxLineLength if 0.
+	MaxLineLength int
+}
+
+// Info reads Intel HEX from r and returns a summary of its contents and
+// records.
+func Info(r io.Reader) (*Summary, error) {
+	return InfoWithOptions(r, InfoOptions{})
+}
+
+// InfoWithOptions is like Info but reads the input as configured by opts.
+func InfoWithOptions(r io.Reader, opts InfoOptions) (*Summary, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	s := NewScanner(bytes.NewReader(data))
+	s.SetMode(opts.Mode)
+	if opts.MaxLineLength != 0 {
+		s.SetMaxLineLength(opts.MaxLineLength)
+	}
+	img, err := ReadImageWithPolicy(s, opts.Policy)
+	if err != nil {
+		return nil, err
+	}
+	summary := img.Summary()
+
+	var (
+		records = new(RecordSummary)
+		rr      = NewRecordReader(bytes.NewReader(data))
+	)
+	rr.SetMode(opts.Mode)
+	if opts.MaxLineLength != 0 {
+		rr.SetMaxLineLength(opts.MaxLineLength)
+	}
+	for rr.Scan() {
+		record := rr.Record()
+		records.Counts[record.RecordType]++
+		if record.RecordType == RecordTypeData && len(record.Data) > records.MaxLength {
+			records.MaxLength = len(record.Data)
+		}
+
+		// Only count the records the image was read from
+		if record.RecordType == RecordTypeEOF && opts.Mode&ScanMultipleEOF == 0 {
+			break
+		}
+	}
+	if err := rr.Err(); err != nil {
+		return nil, err
+	}
+
+	switch segment, linear := records.Counts[RecordTypeExtSegAddr] > 0, records.Counts[RecordTypeExtLinAddr] > 0; {
+	case segment && linear:
+		records.Addressing = "mixed"
+	case segment:
+		records.Addressing = "segment"
+	case linear:
+		records.Addressing = "linear"
+	default:
+		records.Addressing = "none"
+	}
+
+	if records.Canonical, err = isCanonical(img, data); err != nil {
+		return nil, err
+	}
+
+	summary.Records = records
+	return summary, nil
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestInfo(t *testing.T) {
+	r := strings.NewReader(`:020000040001F9
+:020000000102FB
+:03001000030405E1
+:020000022000DC
+:01000000AA55
+:0400000500010000F6
+:00000001FF
+`)
+
+	s, err := Info(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ranges := []Range{{0x10000, 0x10001}, {0x10010, 0x10012}, {0x20000, 0x20000}}
+	if !equalRanges(s.Ranges, ranges) {
+		t.Errorf("range mismatch: expected=%v, actual=%v", ranges, s.Ranges)
+	}
+	gaps := []Range{{0x10002, 0x1000F}, {0x10013, 0x1FFFF}}
+	if !equalRanges(s.Gaps, gaps) {
+		t.Errorf("gap mismatch: expected=%v, actual=%v", gaps, s.Gaps)
+	}
+	if s.Size != 6 {
+		t.Errorf("size mismatch: expected=6, actual=%d", s.Size)
+	}
+	if s.Bounds == nil || *s.Bounds != (Range{0x10000, 0x20000}) {
+		t.Errorf("bounds mismatch: expected=0x00010000-0x00020000, actual=%v", s.Bounds)
+	}
+	if s.Start == nil || s.Start.Address() != 0x10000 {
+		t.Errorf("start address mismatch: expected=0x00010000, actual=%v", s.Start)
+	}
+
+	counts := RecordCounts{3, 1, 1, 0, 1, 1}
+	if s.Records == nil {
+		t.Fatalf("expected record summary")
+	}
+	if s.Records.Counts != counts {
+		t.Errorf("count mismatch: expected=%v, actual=%v", counts, s.Records.Counts)
+	}
+	if s.Records.MaxLength != 3 {
+		t.Errorf("max length mismatch: expected=3, actual=%d", s.Records.MaxLength)
+	}
+	if s.Records.Addressing != "mixed" {
+		t.Errorf("addressing mismatch: expected=mixed, actual=%s", s.Records.Addressing)
+	}
+	if s.Records.Canonical {
+		t.Errorf("expected non-canonical file")
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, substr := range []string{
+		`"bounds":{"low":"0x00010000","high":"0x00020000","size":65537}`,
+		`"start":"0x00010000"`,
+		`"extended_segment_address":1`,
+	} {
+		if !strings.Contains(string(data), substr) {
+			t.Errorf("JSON mismatch: expected %s in %s", substr, data)
+		}
+	}
+}
+
+func TestInfoCanonical(t *testing.T) {
+	s, err := Info(strings.NewReader(":0400000001020304F2\n:00000001FF\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !s.Records.Canonical || s.Records.Addressing != "none" {
+		t.Errorf("expected canonical file without address records but got %+v", s.Records)
+	}
+}
+
+func TestInfoConcatenated(t *testing.T) {
+	const input = ":0400000001020304F2\n:00000001FF\n:020010000304E7\n:00000001FF\n"
+
+	var cases = []struct {
+		mode   ScanMode
+		counts RecordCounts
+		size   uint64
+	}{
+		{0, RecordCounts{1, 1, 0, 0, 0, 0}, 4},
+		{ScanMultipleEOF, RecordCounts{2, 2, 0, 0, 0, 0}, 6},
+	}
+
+	for i, tc := range cases {
+		t.Logf("Case %d", i)
+
+		s, err := InfoWithOptions(strings.NewReader(input), InfoOptions{Mode: tc.mode})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if s.Records.Counts != tc.counts {
+			t.Errorf("count mismatch: expected=%v, actual=%v", tc.counts, s.Records.Counts)
+		}
+		if s.Size != tc.size {
+			t.Errorf("size mismatch: expected=%d, actual=%d", tc.size, s.Size)
+		}
+	}
+}
+
+func TestInfoWithOptions(t *testing.T) {
+	const input = ":0400000001020304F2\n:020002000506F1\n:00000001FF\n"
+
+	var cases = []struct {
+		opts InfoOptions
+		err  func(error) bool
+	}{
+		{InfoOptions{}, IsOverlapError},
+		{InfoOptions{Policy: OverlapLastWins}, nil},
+		{InfoOptions{Policy: OverlapLastWins, MaxLineLength: 10}, func(err error) bool { return errors.Is(err, ErrLineTooLong) }},
+	}
+
+	for i, tc := range cases {
+		t.Logf("Case %d", i)
+
+		s, err := InfoWithOptions(strings.NewReader(input), tc.opts)
+		if tc.err != nil {
+			if !tc.err(err) {
+				t.Errorf("unexpected error: %v", err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if s.Size != 4 || s.Records.Counts[RecordTypeData] != 2 {
+			t.Errorf("unexpected summary %+v", s)
+		}
+	}
+}
@@ -1,18 +1,16 @@
 // Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
 // AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
 
-// Package intelhex implements an Intel HEX parser.
+// Package intelhex implements Intel HEX and Motorola S-record parsers.
 package intelhex
 
 import (
-	"bufio"
 	"bytes"
 	"encoding/binary"
-	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"strings"
 )
 
 // Checksum returns the two's complement checksum described here:
@@ -69,16 +67,27 @@ func (x *Record) MarshalBinary() (data []byte, err error) {
 
 	// Check that the byte count and and data lengths match
 	if len(x.Data) != int(x.ByteCount) {
-		err = byteCountMismatchError{int(x.ByteCount), len(data)}
+		err = byteCountMismatchError{int(x.ByteCount), len(x.Data)}
+		return
 	}
 
 	// Verify extended addresses have a byte count of 2
 	if x.RecordType == RecordTypeExtSegAddr && x.ByteCount != 0x02 {
-		err = fmt.Errorf("expected extended segment address record type to have byte count of 0x02 but got 0x%02X", x.ByteCount)
+		err = recordLengthError(fmt.Sprintf("expected extended segment address record type to have byte count of 0x02 but got 0x%02X", x.ByteCount))
 		return
 	}
 	if x.RecordType == RecordTypeExtLinAddr && x.ByteCount != 0x02 {
-		err = fmt.Errorf("expected extended linear address record type to have byte count of 0x02 but got 0x%02X", x.ByteCount)
+		err = recordLengthError(fmt.Sprintf("expected extended linear address record type to have byte count of 0x02 but got 0x%02X", x.ByteCount))
+		return
+	}
+
+	// Verify start addresses have a byte count of 4
+	if x.RecordType == RecordTypeStartSegAddr && x.ByteCount != 0x04 {
+		err = recordLengthError(fmt.Sprintf("expected start segment address record type to have byte count of 0x04 but got 0x%02X", x.ByteCount))
+		return
+	}
+	if x.RecordType == RecordTypeStartLinAddr && x.ByteCount != 0x04 {
+		err = recordLengthError(fmt.Sprintf("expected start linear address record type to have byte count of 0x04 but got 0x%02X", x.ByteCount))
 		return
 	}
 
@@ -136,21 +145,25 @@ func (err byteCountMismatchError) Error() string {
 	return fmt.Sprintf("byte count was %d but data length was %d", err.byteCount, err.dataLength)
 }
 
-// IsChecksumError returns true if the given error was caused by a checksum error.
+func (err byteCountMismatchError) Is(target error) bool {
+	return target == ErrRecordLength
+}
+
+// IsChecksumError returns true if the given error, or any error it wraps, was
+// caused by a checksum error.
 func IsChecksumError(err error) bool {
-	_, ok := err.(checksumError)
-	return ok
+	return errors.Is(err, ErrChecksum)
 }
 
-// IsInvalidRecordTypeError returns true if the given error was caused by an
-// unsupported record type.
+// IsInvalidRecordTypeError returns true if the given error, or any error it
+// wraps, was caused by an unsupported record type.
 func IsInvalidRecordTypeError(err error) bool {
-	_, ok := err.(invalidRecordTypeError)
-	return ok
+	return errors.Is(err, ErrInvalidRecordType)
 }
 
 // UnmarshalBinary decodes a record from the given data or returns an error.
-// The functions IsChecksumError or IsInvalidRecordTypeError can be used to
+// The functions IsChecksumError or IsInvalidRecordTypeError, or errors.Is with
+// ErrChecksum, ErrInvalidRecordType or ErrRecordLength, can be used to
 // determine the type of error.
 func (x *Record) UnmarshalBinary(data []byte) (err error) {
 	r := bytes.NewReader(data)
@@ -158,16 +171,16 @@ func (x *Record) UnmarshalBinary(data []byte) (err error) {
 	// Decode all the fields
 	err = binary.Read(r, binary.BigEndian, &x.ByteCount)
 	if err != nil {
-		return fmt.Errorf("error decoding byte count field: %v", err)
+		return recordLengthError(fmt.Sprintf("error decoding byte count field: %v", err))
 	}
 	err = binary.Read(r, binary.BigEndian, &x.Address)
 	if err != nil {
-		return fmt.Errorf("error decoding address field: %v", err)
+		return recordLengthError(fmt.Sprintf("error decoding address field: %v", err))
 	}
 
 	err = binary.Read(r, binary.BigEndian, &x.RecordType)
 	if err != nil {
-		return fmt.Errorf("error decoding record type field: %v", err)
+		return recordLengthError(fmt.Sprintf("error decoding record type field: %v", err))
 	}
 	if x.RecordType >= NumRecordTypes {
 		return invalidRecordTypeError(x.RecordType)
@@ -175,26 +188,34 @@ func (x *Record) UnmarshalBinary(data []byte) (err error) {
 
 	// Verify extended addresses have a byte count of 2
 	if x.RecordType == RecordTypeExtSegAddr && x.ByteCount != 0x02 {
-		return fmt.Errorf("expected extended segment address record type to have byte count of 0x02 but got 0x%02X", x.ByteCount)
+		return recordLengthError(fmt.Sprintf("expected extended segment address record type to have byte count of 0x02 but got 0x%02X", x.ByteCount))
 	}
 	if x.RecordType == RecordTypeExtLinAddr && x.ByteCount != 0x02 {
-		return fmt.Errorf("expected extended linear address record type to have byte count of 0x02 but got 0x%02X", x.ByteCount)
+		return recordLengthError(fmt.Sprintf("expected extended linear address record type to have byte count of 0x02 but got 0x%02X", x.ByteCount))
+	}
+
+	// Verify start addresses have a byte count of 4
+	if x.RecordType == RecordTypeStartSegAddr && x.ByteCount != 0x04 {
+		return recordLengthError(fmt.Sprintf("expected start segment address record type to have byte count of 0x04 but got 0x%02X", x.ByteCount))
+	}
+	if x.RecordType == RecordTypeStartLinAddr && x.ByteCount != 0x04 {
+		return recordLengthError(fmt.Sprintf("expected start linear address record type to have byte count of 0x04 but got 0x%02X", x.ByteCount))
 	}
 
 	x.Data = make([]byte, x.ByteCount)
 	if len(x.Data) > 0 {
 		err = binary.Read(r, binary.BigEndian, &x.Data)
 		if err != nil {
-			return fmt.Errorf("error decoding data field: %v", err)
+			return recordLengthError(fmt.Sprintf("error decoding data field: %v", err))
 		}
 	}
 	err = binary.Read(r, binary.BigEndian, &x.Checksum)
 	if err != nil {
-		return fmt.Errorf("error decoding checksum field: %v", err)
+		return recordLengthError(fmt.Sprintf("error decoding checksum field: %v", err))
 	}
 
 	if r.Len() != 0 {
-		return fmt.Errorf("unexpected %d bytes left", r.Len())
+		return recordLengthError(fmt.Sprintf("unexpected %d bytes left", r.Len()))
 	}
 
 	// Validate the checksum
@@ -215,111 +236,281 @@ func (err checksumError) Error() string {
 	return fmt.Sprintf("expected checksum 0x%02X but calculated 0x%02X", err.expected, err.calculated)
 }
 
+func (err checksumError) Is(target error) bool {
+	return target == ErrChecksum
+}
+
 type invalidRecordTypeError byte
 
 func (err invalidRecordTypeError) Error() string {
 	return fmt.Sprintf("invalid record type 0x%02X", byte(err))
 }
 
+func (err invalidRecordTypeError) Is(target error) bool {
+	return target == ErrInvalidRecordType
+}
+
+// StartAddress is the execution start address given by a start segment
+// address record (CS:IP) or a start linear address record (EIP).
+type StartAddress struct {
+	// RecordType is either RecordTypeStartSegAddr or RecordTypeStartLinAddr.
+	RecordType byte
+
+	// Value holds the record's 4 data bytes: CS in the upper and IP in the
+	// lower 16 bits for a start segment address, or EIP for a start linear
+	// address.
+	Value uint32
+}
+
+// NewStartSegmentAddress returns a CS:IP start address.
+func NewStartSegmentAddress(cs, ip uint16) *StartAddress {
+	return &StartAddress{RecordTypeStartSegAddr, uint32(cs)<<16 | uint32(ip)}
+}
+
+// NewStartLinearAddress returns an EIP start address.
+func NewStartLinearAddress(eip uint32) *StartAddress {
+	return &StartAddress{RecordTypeStartLinAddr, eip}
+}
+
+// CS returns the code segment of a start segment address.
+func (sa *StartAddress) CS() uint16 { return uint16(sa.Value >> 16) }
+
+// IP returns the instruction pointer of a start segment address.
+func (sa *StartAddress) IP() uint16 { return uint16(sa.Value) }
+
+// Address returns the physical start address, i.e. CS*16+IP for a start
+// segment address or EIP for a start linear address.
+func (sa *StartAddress) Address() uint32 {
+	if sa.RecordType == RecordTypeStartSegAddr {
+		return uint32(sa.CS())<<4 + uint32(sa.IP())
+	}
+	return sa.Value
+}
+
+// Record returns the start address record for sa.
+func (sa *StartAddress) Record() *Record {
+	return NewRecord(sa.RecordType, 0, []byte{
+		byte(sa.Value >> 24),
+		byte(sa.Value >> 16),
+		byte(sa.Value >> 8),
+		byte(sa.Value >> 0),
+	})
+}
+
+func (sa *StartAddress) String() string {
+	if sa.RecordType == RecordTypeStartSegAddr {
+		return fmt.Sprintf("%04X:%04X", sa.CS(), sa.IP())
+	}
+	return fmt.Sprintf("0x%08X", sa.Value)
+}
+
+// MarshalText encodes the start address as returned by String.
+func (sa *StartAddress) MarshalText() ([]byte, error) {
+	return []byte(sa.String()), nil
+}
+
 type Scanner struct {
-	scanner  *bufio.Scanner
+	reader   *RecordReader
 	firstErr error
-
-	extendedSegmentedAddressBase uint32
-	extendedLinearAddressBase    uint32
+	done     bool
+	sawEOF   bool
 
 	segment Segment
+	pending []Segment
+	line    int
+	start   *StartAddress
 }
 
 func NewScanner(r io.Reader) *Scanner {
 	return &Scanner{
-		scanner: bufio.NewScanner(r),
+		reader: NewRecordReader(r),
 	}
 }
 
 func (s *Scanner) Err() error {
-	if s.firstErr != nil {
-		return s.firstErr
-	}
-	return s.scanner.Err()
+	return s.firstErr
+}
+
+// SetMode sets the scan mode. It must be called before the first call to Scan.
+// In lenient mode a missing EOF record and anything following the EOF record
+// are reported as diagnostics too, while ScanAllowMissingEOF reports a missing
+// EOF record as a warning.
+func (s *Scanner) SetMode(mode ScanMode) {
+	s.reader.SetMode(mode)
+}
+
+// SetMaxLineLength sets the longest line accepted, which defaults to
+// DefaultMaxLineLength. Limits below 1 are treated as 1. It must be called
+// before the first call to Scan.
+func (s *Scanner) SetMaxLineLength(max int) {
+	s.reader.SetMaxLineLength(max)
+}
+
+// Diagnostics returns the problems found so far in lenient mode that would
+// otherwise have stopped the scan.
+func (s *Scanner) Diagnostics() []*ParseError {
+	return s.reader.Diagnostics()
+}
+
+// Warnings returns the noteworthy but valid or explicitly allowed input found
+// so far, such as records straddling a 64 KiB segment boundary or a missing EOF
+// record with ScanAllowMissingEOF.
+func (s *Scanner) Warnings() []*ParseError {
+	return s.reader.Warnings()
 }
 
 func (s *Scanner) Scan() bool {
-	if s.firstErr != nil {
+	if s.firstErr != nil || s.done {
 		return false
 	}
 
-	for s.scanner.Scan() {
-		hexData := s.scanner.Bytes()
-		if len(hexData) == 0 {
-			continue // skip empty lines
-		}
-
-		// Check for the start code
-		if hexData[0] != StartCode {
-			s.firstErr = fmt.Errorf("expected start code %c but got %c", StartCode, hexData[0])
-			return false
-		}
-
-		src := hexData[1:]
-		dst := make([]byte, hex.DecodedLen(len(src)))
-		_, s.firstErr = hex.Decode(dst, src)
-		if s.firstErr != nil {
-			return false
-		}
+	// Return the second half of a record that wrapped around
+	if len(s.pending) > 0 {
+		s.segment, s.pending = s.pending[0], s.pending[1:]
+		return true
+	}
 
-		// Decode the record
-		var record Record
-		s.firstErr = (&record).UnmarshalBinary(dst)
-		if s.firstErr != nil {
-			return false
-		}
+	for s.reader.Scan() {
+		record := s.reader.Record()
+		s.sawEOF = record.RecordType == RecordTypeEOF
 
 		switch record.RecordType {
 		case RecordTypeData:
-			var addressBase uint32
+			s.line = record.Line
+			s.pending = s.place(record)
+			s.segment, s.pending = s.pending[0], s.pending[1:]
+
+			// Return this segment, skipping any error checks
+			return true
 
-			// Only one should be non-zero at a time so the order shouldn't matter
-			if s.extendedSegmentedAddressBase != 0 {
-				addressBase = s.extendedSegmentedAddressBase
+		case RecordTypeEOF:
+			if s.reader.mode&ScanMultipleEOF != 0 {
+				// Another section may follow
+				s.reader.resetAddressBase()
+				continue
 			}
-			if s.extendedLinearAddressBase != 0 {
-				addressBase = s.extendedLinearAddressBase
+
+			s.done = true
+			if s.reader.mode&(ScanLenient|ScanStrictEOF) != 0 {
+				s.checkTrailing()
 			}
+			return false // return with no error unless content followed
 
-			s.segment.Address = addressBase + uint32(record.Address)
+		case RecordTypeStartSegAddr, RecordTypeStartLinAddr:
+			s.start = &StartAddress{
+				RecordType: record.RecordType,
+				Value:      binary.BigEndian.Uint32(record.Data),
+			}
+		}
+	}
 
-			s.segment.Data = make([]byte, len(record.Data))
-			copy(s.segment.Data, record.Data)
+	s.firstErr = s.reader.Err()
+	if s.firstErr == nil && !s.sawEOF {
+		err := &ParseError{Line: s.reader.Line(), Err: ErrMissingEOF}
+		switch {
+		case s.reader.mode&ScanAllowMissingEOF != 0:
+			s.reader.warn(err)
+		case s.reader.mode&ScanLenient != 0:
+			s.reader.diagnose(err)
+		default:
+			s.firstErr = err
+		}
+	}
+	s.done = true
 
-			// Return this segment, skipping any error checks
-			return true
+	return false
+}
 
-		case RecordTypeEOF:
-			return false // return with no error
+// place returns the segments holding the data of a data record. A record
+// running past the end of its 64 KiB segment is reported as a warning and,
+// unless flat addressing is used, wraps around to the start of the segment for
+// segment addressing or to address 0 past the end of the 32-bit address space
+// for linear addressing. Records before the first address record are treated as
+// segment addressed.
+func (s *Scanner) place(record LineRecord) []Segment {
+	data := make([]byte, len(record.Data))
+	copy(data, record.Data)
+
+	if int(record.Address)+len(data) <= 0x10000 {
+		return []Segment{{record.AbsoluteAddress, data}}
+	}
 
-		case RecordTypeExtSegAddr:
-			s.extendedSegmentedAddressBase = ((uint32(record.Data[0]) << 8) | uint32(record.Data[1])) << 4
-			s.extendedLinearAddressBase = 0
+	s.reader.warn(&ParseError{
+		Line: record.Line,
+		Text: string(s.reader.lines.text),
+		Err:  ErrSegmentBoundary,
+	})
 
-		case RecordTypeExtLinAddr:
-			s.extendedSegmentedAddressBase = 0
-			s.extendedLinearAddressBase = ((uint32(record.Data[0]) << 8) | uint32(record.Data[1])) << 16
+	// Find the number of bytes before the wrap around and where the rest goes
+	var (
+		n       uint64
+		wrapped uint32
+	)
+	switch {
+	case s.reader.mode&ScanFlatAddressing != 0:
+		return []Segment{{record.AbsoluteAddress, data}}
+	case s.reader.linear:
+		n = addressSpaceSize - uint64(record.AbsoluteAddress)
+		if n >= uint64(len(data)) {
+			return []Segment{{record.AbsoluteAddress, data}}
 		}
+		wrapped = 0
+	default:
+		n = 0x10000 - uint64(record.Address)
+		wrapped = record.AbsoluteAddress - uint32(record.Address)
 	}
 
-	s.firstErr = s.scanner.Err()
-	if s.firstErr == nil {
-		s.firstErr = fmt.Errorf("unexpected EOF")
+	return []Segment{
+		{record.AbsoluteAddress, data[:n]},
+		{wrapped, data[n:]},
 	}
+}
 
-	return false
+// checkTrailing reads the rest of the input after the EOF record and reports
+// any content found there, as diagnostics in lenient mode or else as an
+// error.
+func (s *Scanner) checkTrailing() {
+	lines := s.reader.lines
+	for lines.next() {
+		if len(bytes.TrimSpace(lines.text)) == 0 {
+			continue
+		}
+
+		err := &ParseError{
+			Line: lines.line,
+			Text: string(lines.text),
+			Err:  ErrAfterEOF,
+		}
+		if s.reader.mode&ScanLenient != 0 {
+			s.reader.diagnose(err)
+			continue
+		}
+		s.firstErr = err
+		return
+	}
+	s.firstErr = lines.Err()
 }
 
 func (s *Scanner) Segment() Segment {
 	return s.segment
 }
 
+// Line returns the line number of the data record of the current segment.
+func (s *Scanner) Line() int {
+	return s.line
+}
+
+// StartAddress returns the start address read so far or nil if the input has
+// not contained a start address record. Since the record may appear anywhere
+// in the file it should be checked once Scan has returned false.
+func (s *Scanner) StartAddress() *StartAddress {
+	if s.start == nil {
+		return nil
+	}
+	start := *s.start
+	return &start
+}
+
 type Segment struct {
 	Address uint32
 	Data    []byte
@@ -345,68 +536,51 @@ func (s SegmentSlice) Size() uint32 {
 	return (ls.Address + uint32(len(ls.Data))) - fs.Address
 }
 
+const (
+	// DefaultRecordSize is the maximum number of data bytes written per data
+	// record unless configured otherwise.
+	DefaultRecordSize = 16
+
+	// MaxRecordSize is the largest number of data bytes a record can hold.
+	MaxRecordSize = 0xFF
+)
+
+// Write writes the segments in Intel HEX format followed by an EOF record.
+// Segments are split into data records of at most DefaultRecordSize bytes.
 func (s SegmentSlice) Write(w io.Writer) error {
-	// Keep track of the address offset
-	var extendedLinearAddressBase uint32
-
-	for _, seg := range s {
-		// Check if we need to output a new address base
-		base := seg.Address >> 16
-		if base != extendedLinearAddressBase {
-			// Save the base so we don't write the extended record multiple times
-			extendedLinearAddressBase = base
-
-			// Write the
-			record := NewRecord(RecordTypeExtLinAddr, 0, []byte{
-				byte(base >> 8),
-				byte(base >> 0),
-			})
-			d, err := record.MarshalBinary()
-			if err != nil {
-				return err
-			}
-			fmt.Fprint(w, string(StartCode))
-			_, err = w.Write([]byte(strings.ToUpper(hex.EncodeToString(d))))
-			if err != nil {
-				return err
-			}
-			fmt.Fprintln(w, "")
-		}
+	return s.writeRecords(w, DefaultRecordSize, nil)
+}
 
-		// Write the data record
-		record := NewRecord(RecordTypeData, uint16(seg.Address&0xFFFF), seg.Data)
-		d, err := record.MarshalBinary()
-		if err != nil {
-			return err
-		}
-		fmt.Fprint(w, string(StartCode))
-		_, err = w.Write([]byte(strings.ToUpper(hex.EncodeToString(d))))
-		if err != nil {
-			return err
-		}
-		fmt.Fprintln(w, "")
-	}
+// WriteWithStartAddress is like Write but also writes a start address record
+// just before the EOF record if start is not nil.
+func (s SegmentSlice) WriteWithStartAddress(w io.Writer, start *StartAddress) error {
+	return s.writeRecords(w, DefaultRecordSize, start)
+}
 
-	// Write the EOF record
-	d, err := EOFRecord.MarshalBinary()
-	if err != nil {
-		return err
-	}
-	fmt.Fprint(w, string(StartCode))
-	_, err = w.Write([]byte(strings.ToUpper(hex.EncodeToString(d))))
-	if err != nil {
-		return err
-	}
-	fmt.Fprintln(w, "")
+// WriteWithRecordSize is like Write but splits segments into data records of
+// at most recordSize bytes, which must be between 1 and MaxRecordSize.
+func (s SegmentSlice) WriteWithRecordSize(w io.Writer, recordSize int) error {
+	return s.writeRecords(w, recordSize, nil)
+}
 
-	return nil
+func (s SegmentSlice) writeRecords(w io.Writer, recordSize int, start *StartAddress) error {
+	e := NewEncoder(w)
+	e.RecordSize = recordSize
+	e.StartAddress = start
+	return e.Encode(s)
 }
 
 func (s SegmentSlice) WriteFile(filename string) error {
+	return s.WriteFileWithStartAddress(filename, nil)
+}
+
+// WriteFileWithStartAddress is like WriteFile but also writes a start address
+// record if start is not nil.
+func (s SegmentSlice) WriteFileWithStartAddress(filename string, start *StartAddress) error {
 	f, err := os.Create(filename)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
-	return s.Write(f)
+	return s.WriteWithStartAddress(f, start)
 }
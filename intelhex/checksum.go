@@ -0,0 +1,54 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/awarepoint/go-intelhex"
+)
+
+var checksumCommand = &command{
+	name:  "checksum",
+	args:  "IN",
+	short: "Compute checksums and digests over an address range",
+	run:   runChecksum,
+}
+
+func runChecksum(c *command, args []string) {
+	fs := c.flagSet()
+	var (
+		in         = addInputFlags(fs)
+		algorithms = fs.String("algorithm", "crc32", "comma separated `names` of the algorithms: crc16-ccitt, crc32, crc32c, adler32, sha1 or sha256")
+		argRange   = fs.String("range", "", "address `range` to hash, LOW-HIGH or LOW+SIZE (default lowest to highest address)")
+		fill       = fs.String("fill", "0xFF", "`byte` hashed for addresses holding no data")
+	)
+	args = c.parse(fs, args, 1, 1)
+
+	var hashes []intelhex.HashAlgorithm
+	for _, name := range strings.Split(*algorithms, ",") {
+		a, err := intelhex.ParseHashAlgorithm(strings.TrimSpace(name))
+		if err != nil {
+			fatalf("Error parsing flags: %v\n", err)
+		}
+		hashes = append(hashes, a)
+	}
+	v, err := parseByte(*fill)
+	if err != nil {
+		fatalf("Error parsing flags: %v\n", err)
+	}
+
+	img := in.mustLoad(args[0])
+
+	r := mustRangeOrBounds(*argRange, img)
+
+	for _, a := range hashes {
+		sum, err := img.Checksum(a, r, v)
+		if err != nil {
+			fatalf("Error computing %v: %v\n", a, err)
+		}
+		fmt.Printf("%-12s %v  %x\n", a, r, sum)
+	}
+}
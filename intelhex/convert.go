@@ -0,0 +1,23 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package main
+
+var convertCommand = &command{
+	name:  "convert",
+	args:  "IN OUT",
+	short: "Convert an image between file formats",
+	run:   runConvert,
+}
+
+func runConvert(c *command, args []string) {
+	fs := c.flagSet()
+	var (
+		in  = addInputFlags(fs)
+		out = addOutputFlags(fs)
+	)
+	args = c.parse(fs, args, 2, 2)
+
+	img := in.mustLoad(args[0])
+	out.mustSave(img, args[1])
+}
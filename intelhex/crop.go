@@ -0,0 +1,35 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package main
+
+var cropCommand = &command{
+	name:  "crop",
+	args:  "IN OUT",
+	short: "Keep only the data inside or outside of address ranges",
+	run:   runCrop,
+}
+
+func runCrop(c *command, args []string) {
+	fs := c.flagSet()
+	var (
+		in      = addInputFlags(fs)
+		out     = addOutputFlags(fs)
+		keep    rangeList
+		exclude rangeList
+	)
+	fs.Var(&keep, "range", "address `range` to keep, LOW-HIGH or LOW+SIZE; may be repeated")
+	fs.Var(&exclude, "exclude", "address `range` to remove, LOW-HIGH or LOW+SIZE; may be repeated")
+	args = c.parse(fs, args, 2, 2)
+
+	if len(keep) == 0 && len(exclude) == 0 {
+		fatalf("Error parsing flags: at least one -range or -exclude must be given\n")
+	}
+
+	img := in.mustLoad(args[0])
+	if len(keep) > 0 {
+		img.Crop(keep...)
+	}
+	img.Exclude(exclude...)
+	out.mustSave(img, args[1])
+}
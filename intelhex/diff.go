@@ -0,0 +1,53 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/awarepoint/go-intelhex"
+)
+
+var diffCommand = &command{
+	name:  "diff",
+	args:  "A B",
+	short: "Compare the memory contents of two images, exiting with status 1 if they differ and 2 on errors",
+	run:   runDiff,
+}
+
+func runDiff(c *command, args []string) {
+	fs := c.flagSet()
+	var (
+		in      = addInputFlags(fs)
+		jsonOut = fs.Bool("json", false, "write the differences as JSON")
+		brief   = fs.Bool("brief", false, "only list the differing address ranges")
+	)
+	args = c.parse(fs, args, 2, 2)
+
+	var (
+		a = in.mustLoadOrExit(args[0], 2)
+		b = in.mustLoadOrExit(args[1], 2)
+		d = intelhex.Compare(a, b)
+	)
+
+	var err error
+	switch {
+	case *jsonOut:
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+		err = e.Encode(d)
+	case *brief:
+		err = d.WriteSummary(os.Stdout, args[0], args[1])
+	default:
+		err = d.WriteText(os.Stdout, args[0], args[1])
+	}
+	if err != nil {
+		exitf(2, "Error writing diff: %v\n", err)
+	}
+
+	if !d.Equal() {
+		os.Exit(1)
+	}
+}
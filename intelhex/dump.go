@@ -0,0 +1,36 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package main
+
+import (
+	"os"
+
+	"github.com/awarepoint/go-intelhex"
+)
+
+var dumpCommand = &command{
+	name:  "dump",
+	args:  "FILE",
+	short: "Print a hex dump of an image",
+	run:   runDump,
+}
+
+func runDump(c *command, args []string) {
+	fs := c.flagSet()
+	var (
+		in    = addInputFlags(fs)
+		width = fs.Int("width", 16, "number of `bytes` per line")
+		group = fs.Int("group", 1, "number of `bytes` written without a space between them")
+		ascii = fs.Bool("ascii", true, "add a column with the printable ASCII characters")
+	)
+	args = c.parse(fs, args, 1, 1)
+
+	img := in.mustLoad(args[0])
+
+	d := intelhex.NewDumper(os.Stdout)
+	d.Width, d.Group, d.ASCII = *width, *group, *ascii
+	if err := d.Dump(img); err != nil {
+		fatalf("Error dumping %s: %v\n", args[0], err)
+	}
+}
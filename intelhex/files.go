@@ -0,0 +1,369 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/awarepoint/go-intelhex"
+)
+
+// detectFormat returns the named format if it is set or the format matching
+// the file's extension.
+func detectFormat(filename, format string) (intelhex.Format, error) {
+	if format != "" {
+		return intelhex.ParseFormat(format)
+	}
+	return intelhex.DetectFormat(filename), nil
+}
+
+// inputOptions are the flags controlling how input files are read.
+type inputOptions struct {
+	format   string
+	overlap  string
+	lenient  bool
+	flat     bool
+	eof      string
+	maxLine  int
+	base     string
+	skipFill string
+	skipRun  int
+}
+
+func addInputFlags(fs *flag.FlagSet) *inputOptions {
+	opts := new(inputOptions)
+	fs.StringVar(&opts.format, "from", "", "input `format`: hex, srec or bin (default from file extension)")
+	fs.StringVar(&opts.overlap, "overlap", "error", "how to handle overlapping data records: error, first, last or differ")
+	fs.BoolVar(&opts.lenient, "lenient", false, "report problems in Intel HEX input and keep reading")
+	fs.BoolVar(&opts.flat, "flat", false, "don't wrap Intel HEX records running past a 64 KiB segment around to its start")
+	fs.StringVar(&opts.eof, "eof", "", "Intel HEX EOF record handling: multiple (concatenated files), strict (fail on trailing content) or optional (allow a missing EOF record)")
+	fs.IntVar(&opts.maxLine, "max-line", intelhex.DefaultMaxLineLength, "longest accepted line `length`")
+	fs.StringVar(&opts.base, "base", "0", "load `address` of binary input")
+	fs.StringVar(&opts.skipFill, "skip-fill", "", "leave out runs of this `byte` from binary input")
+	fs.IntVar(&opts.skipRun, "skip-run", 16, "minimum `length` of the runs left out by -skip-fill")
+	return opts
+}
+
+// load reads an image from the named file or standard input if it is -. For
+// Intel HEX it also returns the problems skipped in lenient mode and the
+// warnings.
+func (opts *inputOptions) load(filename string) (img *intelhex.Image, diagnostics, warnings []*intelhex.ParseError, err error) {
+	if err = opts.validate(); err != nil {
+		return nil, nil, nil, err
+	}
+	format, err := detectFormat(filename, opts.format)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	policy, err := intelhex.ParseOverlapPolicy(opts.overlap)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	r, err := openInput(filename)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer r.Close()
+
+	if format == intelhex.FormatIntelHex {
+		mode, err := opts.scanMode()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		s := intelhex.NewScanner(r)
+		s.SetMaxLineLength(opts.maxLine)
+		s.SetMode(mode)
+		img, err = intelhex.ReadImageWithPolicy(s, policy)
+		return img, s.Diagnostics(), s.Warnings(), err
+	}
+
+	img, err = opts.loadOther(r, format, policy)
+	return img, nil, nil, err
+}
+
+// validate checks the flags that aren't checked when they are used.
+func (opts *inputOptions) validate() error {
+	if opts.maxLine < 1 {
+		return fmt.Errorf("line length must be at least 1 but got %d", opts.maxLine)
+	}
+	return nil
+}
+
+// openInput opens the named file or standard input if it is -.
+func openInput(filename string) (io.ReadCloser, error) {
+	if filename == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(filename)
+}
+
+// scanMode returns the scan mode for Intel HEX input selected by the flags.
+func (opts *inputOptions) scanMode() (intelhex.ScanMode, error) {
+	var mode intelhex.ScanMode
+	if opts.lenient {
+		mode |= intelhex.ScanLenient
+	}
+	if opts.flat {
+		mode |= intelhex.ScanFlatAddressing
+	}
+	switch opts.eof {
+	case "":
+	case "multiple":
+		mode |= intelhex.ScanMultipleEOF
+	case "strict":
+		mode |= intelhex.ScanStrictEOF
+	case "optional":
+		mode |= intelhex.ScanAllowMissingEOF
+	default:
+		return 0, fmt.Errorf("unknown EOF handling %q", opts.eof)
+	}
+	return mode, nil
+}
+
+// loadOther reads an image in any format but Intel HEX.
+func (opts *inputOptions) loadOther(r io.Reader, format intelhex.Format, policy intelhex.OverlapPolicy) (*intelhex.Image, error) {
+	switch format {
+	case intelhex.FormatSRecord:
+		s := intelhex.NewSRecordScanner(r)
+		s.SetMaxLineLength(opts.maxLine)
+		return intelhex.ReadImageWithPolicy(s, policy)
+	case intelhex.FormatBinary:
+		base, err := parseAddress(opts.base)
+		if err != nil {
+			return nil, err
+		}
+		if opts.skipFill == "" {
+			return intelhex.ReadBinary(r, base)
+		}
+		fill, err := parseByte(opts.skipFill)
+		if err != nil {
+			return nil, err
+		}
+		return intelhex.ReadBinarySparse(r, base, fill, opts.skipRun)
+	default:
+		return nil, fmt.Errorf("unknown format %v", format)
+	}
+}
+
+// mustLoad is like load but exits on error and prints any problems found in
+// lenient mode and the warnings.
+func (opts *inputOptions) mustLoad(filename string) *intelhex.Image {
+	return opts.mustLoadOrExit(filename, 1)
+}
+
+// mustLoadOrExit is like mustLoad but exits with the given status on error.
+func (opts *inputOptions) mustLoadOrExit(filename string, status int) *intelhex.Image {
+	img, diagnostics, warnings, err := opts.load(filename)
+	for _, d := range diagnostics {
+		infof("Problem: %s\n", position(filename, d))
+	}
+	for _, w := range warnings {
+		infof("Warning: %s\n", position(filename, w))
+	}
+	if err != nil {
+		exitf(status, "Error reading %s: %v\n", filename, err)
+	}
+	return img
+}
+
+// outputOptions are the flags controlling how output files are written.
+type outputOptions struct {
+	format     string
+	recordSize int
+	fill       string
+	window     string
+	truncate   bool
+	start      string
+	straddle   bool
+}
+
+func addOutputFlags(fs *flag.FlagSet) *outputOptions {
+	opts := new(outputOptions)
+	fs.StringVar(&opts.format, "to", "", "output `format`: hex, srec or bin (default from file extension)")
+	fs.IntVar(&opts.recordSize, "record-size", intelhex.DefaultRecordSize, "maximum number of data `bytes` per record")
+	fs.StringVar(&opts.fill, "fill", "0xFF", "`bytes` used to fill gaps in binary output, a comma separated pattern such as 0xDE,0xAD")
+	fs.StringVar(&opts.window, "window", "", "address `range` of binary output, LOW-HIGH or LOW+SIZE (default from the lowest to the highest address)")
+	fs.BoolVar(&opts.truncate, "truncate", false, "drop data outside of -window from binary output with a warning instead of failing")
+	fs.BoolVar(&opts.straddle, "straddle", false, "let Intel HEX records run past the end of a 64 KiB segment")
+	fs.StringVar(&opts.start, "start", "", "set the start linear `address` of the output")
+	return opts
+}
+
+// save writes an image to the named file or standard output if it is -.
+func (opts *outputOptions) save(img *intelhex.Image, filename string) error {
+	format, err := detectFormat(filename, opts.format)
+	if err != nil {
+		return err
+	}
+	fill, err := parseBytes(opts.fill)
+	if err != nil {
+		return err
+	}
+	window, ok := img.Bounds()
+	if opts.window != "" {
+		if window, err = parseRange(opts.window); err != nil {
+			return err
+		}
+		ok = true
+	}
+	if opts.start != "" {
+		start, err := parseAddress(opts.start)
+		if err != nil {
+			return err
+		}
+		img.Start = intelhex.NewStartLinearAddress(start)
+	}
+
+	// Flatten binary output before creating the file so that a refused
+	// truncation doesn't leave an empty file behind
+	var data []byte
+	if format == intelhex.FormatBinary && ok {
+		data, err = img.Flatten(window, fill...)
+		if intelhex.IsTruncatedError(err) && opts.truncate {
+			infof("Warning: %v\n", err)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if filename != "-" {
+		f, err := os.Create(filename)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch format {
+	case intelhex.FormatSRecord:
+		e := intelhex.NewSRecordEncoder(w)
+		e.RecordSize = opts.recordSize
+		return img.EncodeSRecords(e)
+	case intelhex.FormatBinary:
+		_, err = w.Write(data)
+		return err
+	default:
+		e := intelhex.NewEncoder(w)
+		e.RecordSize = opts.recordSize
+		e.FlatAddressing = opts.straddle
+		return img.Encode(e)
+	}
+}
+
+// mustSave is like save but exits on error.
+func (opts *outputOptions) mustSave(img *intelhex.Image, filename string) {
+	if err := opts.save(img, filename); err != nil {
+		fatalf("Error writing %s: %v\n", filename, err)
+	}
+}
+
+// position formats a parse error in the usual file:line:column: form.
+func position(filename string, err *intelhex.ParseError) string {
+	if err.Column == 0 {
+		return fmt.Sprintf("%s:%d: %v", filename, err.Line, err.Err)
+	}
+	return fmt.Sprintf("%s:%d:%d: %v", filename, err.Line, err.Column, err.Err)
+}
+
+// parseAddress parses a 32-bit address in decimal, or hex with a 0x prefix.
+func parseAddress(s string) (uint32, error) {
+	v, err := strconv.ParseUint(s, 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid address %q", s)
+	}
+	return uint32(v), nil
+}
+
+func parseByte(s string) (byte, error) {
+	v, err := strconv.ParseUint(s, 0, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte %q", s)
+	}
+	return byte(v), nil
+}
+
+// parseBytes parses a comma separated list of bytes.
+func parseBytes(s string) ([]byte, error) {
+	fields := strings.Split(s, ",")
+	data := make([]byte, len(fields))
+	for i, f := range fields {
+		b, err := parseByte(strings.TrimSpace(f))
+		if err != nil {
+			return nil, err
+		}
+		data[i] = b
+	}
+	return data, nil
+}
+
+// parseRange parses an inclusive address range written as LOW-HIGH or
+// LOW+SIZE.
+func parseRange(s string) (r intelhex.Range, err error) {
+	if i := strings.IndexAny(s, "-+"); i > 0 {
+		if r.Low, err = parseAddress(s[:i]); err != nil {
+			return
+		}
+		var v uint32
+		if v, err = parseAddress(s[i+1:]); err != nil {
+			return
+		}
+		if s[i] == '-' {
+			r.High = v
+		} else if v == 0 || uint64(r.Low)+uint64(v) > 1<<32 {
+			err = fmt.Errorf("invalid range %q", s)
+			return
+		} else {
+			r.High = r.Low + v - 1
+		}
+		if r.High < r.Low {
+			err = fmt.Errorf("invalid range %q", s)
+		}
+		return
+	}
+	err = fmt.Errorf("invalid range %q, expected LOW-HIGH or LOW+SIZE", s)
+	return
+}
+
+// mustRangeOrBounds parses s as an address range or, if it is empty, returns
+// the range from the image's lowest to highest address. It exits if s is
+// invalid or the image is empty.
+func mustRangeOrBounds(s string, img *intelhex.Image) intelhex.Range {
+	if s != "" {
+		r, err := parseRange(s)
+		if err != nil {
+			fatalf("Error parsing flags: %v\n", err)
+		}
+		return r
+	}
+	r, ok := img.Bounds()
+	if !ok {
+		fatalf("No segments found.\n")
+	}
+	return r
+}
+
+// rangeList is a flag that can be given several times to collect address
+// ranges.
+type rangeList []intelhex.Range
+
+func (l *rangeList) String() string {
+	return fmt.Sprint(*l)
+}
+
+func (l *rangeList) Set(s string) error {
+	r, err := parseRange(s)
+	if err != nil {
+		return err
+	}
+	*l = append(*l, r)
+	return nil
+}
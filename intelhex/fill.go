@@ -0,0 +1,36 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package main
+
+var fillCommand = &command{
+	name:  "fill",
+	args:  "IN OUT",
+	short: "Fill the gaps of an image with a byte value",
+	run:   runFill,
+}
+
+func runFill(c *command, args []string) {
+	fs := c.flagSet()
+	var (
+		in       = addInputFlags(fs)
+		out      = addOutputFlags(fs)
+		value    = fs.String("value", "0xFF", "`byte` to fill with")
+		argRange = fs.String("range", "", "address `range` to fill, LOW-HIGH or LOW+SIZE (default lowest to highest address)")
+	)
+	args = c.parse(fs, args, 2, 2)
+
+	v, err := parseByte(*value)
+	if err != nil {
+		fatalf("Error parsing flags: %v\n", err)
+	}
+
+	img := in.mustLoad(args[0])
+
+	r := mustRangeOrBounds(*argRange, img)
+
+	if err := img.Fill(r, v); err != nil {
+		fatalf("Error filling: %v\n", err)
+	}
+	out.mustSave(img, args[1])
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/awarepoint/go-intelhex"
+)
+
+var infoCommand = &command{
+	name:  "info",
+	args:  "FILE",
+	short: "Print the address ranges, size, start address and records of an image",
+	run:   runInfo,
+}
+
+func runInfo(c *command, args []string) {
+	fs := c.flagSet()
+	var (
+		in      = addInputFlags(fs)
+		jsonOut = fs.Bool("json", false, "write the summary as JSON")
+	)
+	args = c.parse(fs, args, 1, 1)
+
+	s, err := in.info(args[0])
+	if err != nil {
+		fatalf("Error reading %s: %v\n", args[0], err)
+	}
+
+	if *jsonOut {
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+		if err := e.Encode(s); err != nil {
+			fatalf("Error writing summary: %v\n", err)
+		}
+		return
+	}
+
+	fmt.Printf("Ranges:\n")
+	for _, r := range s.Ranges {
+		fmt.Printf("  %v  %d bytes\n", r, r.Size())
+	}
+	fmt.Printf("Gaps:\n")
+	for _, r := range s.Gaps {
+		fmt.Printf("  %v  %d bytes\n", r, r.Size())
+	}
+	fmt.Printf("Size: %d bytes\n", s.Size)
+	if s.Bounds != nil {
+		fmt.Printf("Lowest address: 0x%08X\n", s.Bounds.Low)
+		fmt.Printf("Highest address: 0x%08X\n", s.Bounds.High)
+	}
+	if s.Start != nil {
+		fmt.Printf("Start address: %v\n", s.Start)
+	} else {
+		fmt.Printf("Start address: none\n")
+	}
+
+	if r := s.Records; r != nil {
+		fmt.Printf("Records:\n")
+		fmt.Printf("  Data:                     %d\n", r.Counts[intelhex.RecordTypeData])
+		fmt.Printf("  EOF:                      %d\n", r.Counts[intelhex.RecordTypeEOF])
+		fmt.Printf("  Extended segment address: %d\n", r.Counts[intelhex.RecordTypeExtSegAddr])
+		fmt.Printf("  Start segment address:    %d\n", r.Counts[intelhex.RecordTypeStartSegAddr])
+		fmt.Printf("  Extended linear address:  %d\n", r.Counts[intelhex.RecordTypeExtLinAddr])
+		fmt.Printf("  Start linear address:     %d\n", r.Counts[intelhex.RecordTypeStartLinAddr])
+		fmt.Printf("Longest data record: %d bytes\n", r.MaxLength)
+		fmt.Printf("Addressing: %s\n", r.Addressing)
+		fmt.Printf("Canonical: %t\n", r.Canonical)
+	}
+}
+
+// info returns a summary of the named file, including its records if it is
+// Intel HEX.
+func (opts *inputOptions) info(filename string) (*intelhex.Summary, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	format, err := detectFormat(filename, opts.format)
+	if err != nil {
+		return nil, err
+	}
+	if format != intelhex.FormatIntelHex {
+		img, _, _, err := opts.load(filename)
+		if err != nil {
+			return nil, err
+		}
+		return img.Summary(), nil
+	}
+
+	mode, err := opts.scanMode()
+	if err != nil {
+		return nil, err
+	}
+	policy, err := intelhex.ParseOverlapPolicy(opts.overlap)
+	if err != nil {
+		return nil, err
+	}
+	r, err := openInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return intelhex.InfoWithOptions(r, intelhex.InfoOptions{
+		Mode:          mode,
+		Policy:        policy,
+		MaxLineLength: opts.maxLine,
+	})
+}
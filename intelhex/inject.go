@@ -0,0 +1,68 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package main
+
+import (
+	"encoding/binary"
+
+	"github.com/awarepoint/go-intelhex"
+)
+
+var injectCommand = &command{
+	name:  "inject",
+	args:  "IN OUT",
+	short: "Write a checksum or digest of an address range into the image",
+	run:   runInject,
+}
+
+func runInject(c *command, args []string) {
+	fs := c.flagSet()
+	var (
+		in        = addInputFlags(fs)
+		out       = addOutputFlags(fs)
+		algorithm = fs.String("algorithm", "crc32", "`name` of the algorithm: crc16-ccitt, crc32, crc32c, adler32, sha1 or sha256")
+		argRange  = fs.String("range", "", "address `range` to hash, LOW-HIGH or LOW+SIZE (default lowest to highest address)")
+		fill      = fs.String("hash-fill", "0xFF", "`byte` hashed for addresses holding no data")
+		at        = fs.String("at", "", "`address` to write the sum to")
+		endian    = fs.String("endian", "little", "byte `order` of the written sum: little or big")
+	)
+	args = c.parse(fs, args, 2, 2)
+
+	a, err := intelhex.ParseHashAlgorithm(*algorithm)
+	if err != nil {
+		fatalf("Error parsing flags: %v\n", err)
+	}
+	v, err := parseByte(*fill)
+	if err != nil {
+		fatalf("Error parsing flags: %v\n", err)
+	}
+	if *at == "" {
+		fatalf("Error parsing flags: -at must be given\n")
+	}
+	target, err := parseAddress(*at)
+	if err != nil {
+		fatalf("Error parsing flags: %v\n", err)
+	}
+	var order binary.ByteOrder
+	switch *endian {
+	case "little":
+		order = binary.LittleEndian
+	case "big":
+		order = binary.BigEndian
+	default:
+		fatalf("Error parsing flags: unknown byte order %q\n", *endian)
+	}
+
+	img := in.mustLoad(args[0])
+
+	r := mustRangeOrBounds(*argRange, img)
+
+	sum, err := img.InjectHash(a.New(), r, v, target, order)
+	if err != nil {
+		fatalf("Error injecting %v: %v\n", a, err)
+	}
+	infof("Wrote %v of %v at 0x%08X: %x\n", a, r, target, sum)
+
+	out.mustSave(img, args[1])
+}
@@ -1,94 +1,100 @@
 // Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
 // AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
 
+// Command intelhex inspects, converts and combines Intel HEX, Motorola
+// S-record and raw binary firmware images.
 package main
 
 import (
 	"flag"
 	"fmt"
-	"io"
 	"os"
-	"sort"
-
-	"github.com/awarepoint/go-intelhex"
 )
 
-func main() {
-	flag.Parse()
-
-	var (
-		argSrc  = flag.Arg(0)
-		argDest = flag.Arg(1)
-
-		src io.Reader = os.Stdin
-		dst io.Writer = os.Stdout
-	)
+// command is a subcommand of the tool.
+type command struct {
+	name  string
+	args  string
+	short string
+	run   func(c *command, args []string)
+}
 
-	if argSrc != "" {
-		f, err := os.Open(argSrc)
-		if err != nil {
-			fatalf("Error opening source file: %v\n", err)
-		}
-		defer f.Close()
-		src = f
-	}
+var commands = []*command{
+	infoCommand,
+	convertCommand,
+	mergeCommand,
+	fillCommand,
+	cropCommand,
+	relocateCommand,
+	checksumCommand,
+	injectCommand,
+	diffCommand,
+	normalizeCommand,
+	verifyCommand,
+	dumpCommand,
+}
 
-	var (
-		scanner  = intelhex.NewScanner(src)
-		segments = make([]*intelhex.Segment, 0)
-	)
+func main() {
+	flag.Usage = usage
+	flag.Parse()
 
-	// Scan all segments
-	for scanner.Scan() {
-		segment := scanner.Segment()
-		segments = append(segments, &segment)
-	}
-	if err := scanner.Err(); err != nil {
-		fatalf("Error scanning source: %v\n", err)
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(2)
 	}
 
-	if len(segments) == 0 {
-		fatalf("No segments found.\n")
+	name := flag.Arg(0)
+	for _, c := range commands {
+		if c.name == name {
+			c.run(c, flag.Args()[1:])
+			return
+		}
 	}
 
-	// Sort the segments by address
-	sort.Sort(intelhex.SegmentSlice(segments))
-
-	var (
-		sa  = segments[0].Address
-		buf = make([]byte, intelhex.SegmentSlice(segments).Size())
-	)
+	infof("Unknown command %q.\n", name)
+	usage()
+	os.Exit(2)
+}
 
-	// Fill the buffer with 0xFF
-	for i := 0; i < len(buf); i++ {
-		buf[i] = 0xFF
+func usage() {
+	infof("Usage: %s COMMAND [OPTIONS] ARGS...\n\nCommands:\n", os.Args[0])
+	for _, c := range commands {
+		infof("  %-8s %s\n", c.name, c.short)
 	}
+	infof("\nFiles are read and written as Intel HEX, Motorola S-record or raw binary\n" +
+		"depending on their extension unless a format is given. A file name of - is\n" +
+		"standard input or output.\n")
+}
 
-	for _, s := range segments {
-		copy(buf[s.Address-sa:], s.Data)
-	}
-
-	if argDest != "" {
-		f, err := os.Create(argDest)
-		if err != nil {
-			fatalf("Error opening source file: %v\n", err)
-		}
-		defer f.Close()
-		dst = f
+// flagSet returns a flag set for the command that prints its usage on error.
+func (c *command) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(c.name, flag.ExitOnError)
+	fs.Usage = func() {
+		infof("Usage: %s %s [OPTIONS] %s\n\n%s.\n\n", os.Args[0], c.name, c.args, c.short)
+		fs.PrintDefaults()
 	}
+	return fs
+}
 
-	n, err := dst.Write(buf)
-	if n != len(buf) {
-		fatalf("Write to destination did not complete.\n")
-	}
-	if err != nil {
-		fatalf("Error writing to destination: %v\n", err)
+// parse parses the command's flags and checks the number of remaining
+// arguments is between min and max, or at least min if max is negative.
+func (c *command) parse(fs *flag.FlagSet, args []string, min, max int) []string {
+	fs.Parse(args)
+	if fs.NArg() < min || (max >= 0 && fs.NArg() > max) {
+		fs.Usage()
+		os.Exit(2)
 	}
+	return fs.Args()
 }
 
 func fatalf(format string, args ...interface{}) {
+	exitf(1, format, args...)
+}
+
+// exitf prints a message and exits with the given status.
+func exitf(status int, format string, args ...interface{}) {
 	infof(format, args...)
-	os.Exit(1)
+	os.Exit(status)
 }
 
 func infof(format string, args ...interface{}) {
@@ -0,0 +1,94 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/awarepoint/go-intelhex"
+)
+
+var mergeCommand = &command{
+	name:  "merge",
+	args:  "OUT IN[@OFFSET[,PRIORITY]]...",
+	short: "Merge several images into one",
+	run:   runMerge,
+}
+
+func runMerge(c *command, args []string) {
+	fs := c.flagSet()
+	var (
+		in      = addInputFlags(fs)
+		out     = addOutputFlags(fs)
+		mapFile = fs.String("map", "", "write the input each address range came from to `file`")
+	)
+	args = c.parse(fs, args, 2, -1)
+
+	policy, err := intelhex.ParseOverlapPolicy(in.overlap)
+	if err != nil {
+		fatalf("Error parsing flags: %v\n", err)
+	}
+
+	inputs := make([]intelhex.MergeInput, 0, len(args)-1)
+	for _, arg := range args[1:] {
+		input, err := parseMergeInput(arg)
+		if err != nil {
+			fatalf("Error parsing arguments: %v\n", err)
+		}
+		input.Image = in.mustLoad(input.Name)
+		inputs = append(inputs, input)
+	}
+
+	img, provenance, err := intelhex.Merge(policy, inputs...)
+	if err != nil {
+		fatalf("Error merging: %v\n", err)
+	}
+
+	out.mustSave(img, args[0])
+
+	if *mapFile != "" {
+		f, err := os.Create(*mapFile)
+		if err == nil {
+			err = intelhex.WriteMap(f, provenance)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}
+		if err != nil {
+			fatalf("Error writing %s: %v\n", *mapFile, err)
+		}
+	}
+}
+
+// parseMergeInput parses an input file name optionally followed by an address
+// offset and priority, as in app.hex@0x8000,1. Offsets may be negative.
+func parseMergeInput(s string) (input intelhex.MergeInput, err error) {
+	input.Name = s
+
+	i := strings.LastIndexByte(s, '@')
+	if i < 0 {
+		return
+	}
+	input.Name = s[:i]
+
+	offset, priority := s[i+1:], ""
+	if j := strings.IndexByte(offset, ','); j >= 0 {
+		offset, priority = offset[:j], offset[j+1:]
+	}
+	if offset != "" {
+		if input.Offset, err = strconv.ParseInt(offset, 0, 64); err != nil {
+			err = fmt.Errorf("invalid offset %q in %q", offset, s)
+			return
+		}
+	}
+	if priority != "" {
+		if input.Priority, err = strconv.Atoi(priority); err != nil {
+			err = fmt.Errorf("invalid priority %q in %q", priority, s)
+		}
+	}
+	return
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+
+	"github.com/awarepoint/go-intelhex"
+)
+
+var normalizeCommand = &command{
+	name:  "normalize",
+	args:  "IN [OUT]",
+	short: "Rewrite an Intel HEX file in canonical form",
+	run:   runNormalize,
+}
+
+func runNormalize(c *command, args []string) {
+	fs := c.flagSet()
+	check := fs.Bool("check", false, "don't write anything, exit with status 1 if the input isn't canonical")
+	args = c.parse(fs, args, 1, 2)
+
+	var (
+		data []byte
+		err  error
+	)
+	if args[0] == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(args[0])
+	}
+	if err != nil {
+		fatalf("Error reading %s: %v\n", args[0], err)
+	}
+
+	if *check {
+		canonical, err := intelhex.IsCanonical(data)
+		if err != nil {
+			fatalf("Error reading %s: %v\n", args[0], err)
+		}
+		if !canonical {
+			infof("%s is not canonical.\n", args[0])
+			os.Exit(1)
+		}
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := intelhex.Normalize(&buf, bytes.NewReader(data)); err != nil {
+		fatalf("Error reading %s: %v\n", args[0], err)
+	}
+
+	out := "-"
+	if len(args) > 1 {
+		out = args[1]
+	}
+	if out == "-" {
+		_, err = os.Stdout.Write(buf.Bytes())
+	} else {
+		err = os.WriteFile(out, buf.Bytes(), 0666)
+	}
+	if err != nil {
+		fatalf("Error writing %s: %v\n", out, err)
+	}
+}
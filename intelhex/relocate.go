@@ -0,0 +1,57 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package main
+
+import (
+	"strconv"
+)
+
+var relocateCommand = &command{
+	name:  "relocate",
+	args:  "IN OUT",
+	short: "Move all data by an offset or an address range to another address",
+	run:   runRelocate,
+}
+
+func runRelocate(c *command, args []string) {
+	fs := c.flagSet()
+	var (
+		in        = addInputFlags(fs)
+		out       = addOutputFlags(fs)
+		argOffset = fs.String("offset", "", "signed `offset` added to every address")
+		argRange  = fs.String("range", "", "address `range` to move, LOW-HIGH or LOW+SIZE")
+		argDest   = fs.String("dest", "", "`address` the range is moved to")
+	)
+	args = c.parse(fs, args, 2, 2)
+
+	if (*argOffset == "") == (*argRange == "" && *argDest == "") {
+		fatalf("Error parsing flags: either -offset or -range and -dest must be given\n")
+	}
+
+	img := in.mustLoad(args[0])
+
+	if *argOffset != "" {
+		offset, err := strconv.ParseInt(*argOffset, 0, 64)
+		if err != nil {
+			fatalf("Error parsing flags: invalid offset %q\n", *argOffset)
+		}
+		if err := img.Relocate(offset); err != nil {
+			fatalf("Error relocating %s: %v\n", args[0], err)
+		}
+	} else {
+		r, err := parseRange(*argRange)
+		if err != nil {
+			fatalf("Error parsing flags: %v\n", err)
+		}
+		to, err := parseAddress(*argDest)
+		if err != nil {
+			fatalf("Error parsing flags: %v\n", err)
+		}
+		if err := img.Remap(r, to); err != nil {
+			fatalf("Error relocating %s: %v\n", args[0], err)
+		}
+	}
+
+	out.mustSave(img, args[1])
+}
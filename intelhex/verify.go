@@ -0,0 +1,78 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/awarepoint/go-intelhex"
+)
+
+var verifyCommand = &command{
+	name:  "verify",
+	args:  "FILE...",
+	short: "Check that files parse without errors and fit a device's memory map",
+	run:   runVerify,
+}
+
+func runVerify(c *command, args []string) {
+	fs := c.flagSet()
+	var (
+		in      = addInputFlags(fs)
+		profile = fs.String("profile", "", "check the images against the memory map in JSON `file`")
+	)
+	args = c.parse(fs, args, 1, -1)
+
+	var memoryMap *intelhex.MemoryMap
+	if *profile != "" {
+		var err error
+		if memoryMap, err = intelhex.ReadMemoryMapFile(*profile); err != nil {
+			fatalf("Error reading %s: %v\n", *profile, err)
+		}
+	}
+
+	failed := false
+	for _, filename := range args {
+		img, diagnostics, warnings, err := in.load(filename)
+		for _, d := range diagnostics {
+			fmt.Println(position(filename, d))
+		}
+		for _, w := range warnings {
+			fmt.Printf("%s (warning)\n", position(filename, w))
+		}
+		if perr, ok := err.(*intelhex.ParseError); ok {
+			fmt.Println(position(filename, perr))
+		} else if err != nil {
+			fmt.Printf("%s: %v\n", filename, err)
+		}
+		if err != nil || len(diagnostics) > 0 {
+			failed = true
+			continue
+		}
+
+		if memoryMap != nil {
+			violations := memoryMap.Check(img)
+			for _, v := range violations {
+				fmt.Printf("%s: %v\n", filename, v)
+			}
+			if len(violations) > 0 {
+				failed = true
+				continue
+			}
+			for _, u := range memoryMap.Usage(img) {
+				fmt.Printf("%s: %-12s %10d of %10d bytes", filename, u.Region.Name, u.Used, u.Region.Size)
+				if u.Region.PageSize != 0 {
+					fmt.Printf(", %d of %d pages", u.Pages, u.Region.Size/uint64(u.Region.PageSize))
+				}
+				fmt.Println()
+			}
+		}
+		fmt.Printf("%s: OK\n", filename)
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
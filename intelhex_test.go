@@ -6,6 +6,7 @@ package intelhex
 import (
 	"bytes"
 	"encoding/hex"
+	"errors"
 	"io"
 	"strings"
 	"testing"
@@ -454,3 +455,379 @@ func TestSegmentSliceWrite(t *testing.T) {
 		t.Errorf("	  actual=%s", buf.String())
 	}
 }
+
+func TestScannerStartAddress(t *testing.T) {
+	var cases = []struct {
+		r     io.Reader
+		start *StartAddress
+	}{
+		{
+			r: strings.NewReader(`
+:10010000214601360121470136007EFE09D2190140
+:00000001FF`),
+			start: nil,
+		},
+		{
+			r: strings.NewReader(`
+:10010000214601360121470136007EFE09D2190140
+:0400000300003800C1
+:00000001FF`),
+			start: NewStartSegmentAddress(0x0000, 0x3800),
+		},
+		{
+			r: strings.NewReader(`
+:04000005000000CD2A
+:10010000214601360121470136007EFE09D2190140
+:00000001FF`),
+			start: NewStartLinearAddress(0x000000CD),
+		},
+	}
+
+	for i, tc := range cases {
+		t.Logf("Case %d", i)
+
+		s := NewScanner(tc.r)
+		for s.Scan() {
+		}
+		if err := s.Err(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+
+		start := s.StartAddress()
+		if tc.start == nil {
+			if start != nil {
+				t.Errorf("expected no start address but got %v", start)
+			}
+		} else if start == nil {
+			t.Errorf("expected start address %v but got none", tc.start)
+		} else if *start != *tc.start {
+			t.Errorf("start address mismatch: expected=%v, actual=%v", tc.start, start)
+		}
+	}
+}
+
+func TestStartAddress(t *testing.T) {
+	sa := NewStartSegmentAddress(0x1234, 0x0010)
+	if sa.Address() != 0x12350 {
+		t.Errorf("expected address 0x00012350 but got 0x%08X", sa.Address())
+	}
+	if sa.String() != "1234:0010" {
+		t.Errorf("expected 1234:0010 but got %s", sa)
+	}
+
+	sa = NewStartLinearAddress(0x08000131)
+	if sa.Address() != 0x08000131 {
+		t.Errorf("expected address 0x08000131 but got 0x%08X", sa.Address())
+	}
+}
+
+func TestSegmentSliceWriteWithStartAddress(t *testing.T) {
+	ss := SegmentSlice{
+		{
+			Address: 0x0100,
+			Data:    decodeHex("214601360121470136007EFE09D21901"),
+		},
+	}
+
+	exp := `:10010000214601360121470136007EFE09D2190140
+:04000005000000CD2A
+:00000001FF
+`
+
+	buf := &bytes.Buffer{}
+	err := ss.WriteWithStartAddress(buf, NewStartLinearAddress(0x000000CD))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != exp {
+		t.Error("data mismatch")
+		t.Errorf("	expected=%s", exp)
+		t.Errorf("	  actual=%s", buf.String())
+	}
+}
+
+func TestSegmentSliceWriteSplitsRecords(t *testing.T) {
+	data := make([]byte, 40)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	var cases = []struct {
+		recordSize int
+		segments   SegmentSlice
+		records    []LineRecord
+	}{
+		// Segments larger than the record size are split
+		{
+			recordSize: 16,
+			segments:   SegmentSlice{{0x1000, data}},
+			records: []LineRecord{
+				{AbsoluteAddress: 0x1000, Record: Record{ByteCount: 16}},
+				{AbsoluteAddress: 0x1010, Record: Record{ByteCount: 16}},
+				{AbsoluteAddress: 0x1020, Record: Record{ByteCount: 8}},
+			},
+		},
+		{
+			recordSize: 32,
+			segments:   SegmentSlice{{0x1000, data}},
+			records: []LineRecord{
+				{AbsoluteAddress: 0x1000, Record: Record{ByteCount: 32}},
+				{AbsoluteAddress: 0x1020, Record: Record{ByteCount: 8}},
+			},
+		},
+		// Records are split at 64 KiB boundaries
+		{
+			recordSize: 32,
+			segments:   SegmentSlice{{0x0000FFF0, data}},
+			records: []LineRecord{
+				{AbsoluteAddress: 0x0000FFF0, Record: Record{ByteCount: 16}},
+				{AbsoluteAddress: 0x00010000, Record: Record{ByteCount: 24}},
+			},
+		},
+		// Segments longer than 255 bytes
+		{
+			recordSize: MaxRecordSize,
+			segments:   SegmentSlice{{0x0000, make([]byte, 300)}},
+			records: []LineRecord{
+				{AbsoluteAddress: 0x0000, Record: Record{ByteCount: 255}},
+				{AbsoluteAddress: 0x00FF, Record: Record{ByteCount: 45}},
+			},
+		},
+	}
+
+	for i, tc := range cases {
+		t.Logf("Case %d", i)
+
+		buf := &bytes.Buffer{}
+		if err := tc.segments.WriteWithRecordSize(buf, tc.recordSize); err != nil {
+			t.Fatal(err)
+		}
+
+		records := make([]LineRecord, 0)
+		rr := NewRecordReader(buf)
+		for rr.Scan() {
+			if rr.Record().RecordType == RecordTypeData {
+				records = append(records, rr.Record())
+			}
+		}
+		if err := rr.Err(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(records) != len(tc.records) {
+			t.Errorf("record count mismatch: expected=%d, actual=%d", len(tc.records), len(records))
+			continue
+		}
+		for j := range records {
+			if records[j].AbsoluteAddress != tc.records[j].AbsoluteAddress {
+				t.Errorf("    [record %d] address mismatch: expected=0x%08X, actual=0x%08X", j, tc.records[j].AbsoluteAddress, records[j].AbsoluteAddress)
+			}
+			if records[j].ByteCount != tc.records[j].ByteCount {
+				t.Errorf("    [record %d] byte count mismatch: expected=%d, actual=%d", j, tc.records[j].ByteCount, records[j].ByteCount)
+			}
+		}
+	}
+
+	if err := (SegmentSlice{}).WriteWithRecordSize(&bytes.Buffer{}, 256); err == nil {
+		t.Error("expected error for record size 256")
+	}
+}
+
+func TestScannerLenient(t *testing.T) {
+	var cases = []struct {
+		input    string
+		segments int
+		lines    []int
+	}{
+		// Bad checksum is reported but the data is kept
+		{":020000000102FC\n:020010000304E7\n:00000001FF\n", 2, []int{1}},
+		// Bad start code, bad hex and unknown record types are skipped
+		{"020000000102FB\n:02001000030XE7\n:0100000600F9\n:020010000304E7\n:00000001FF\n", 1, []int{1, 2, 3}},
+		// Missing EOF
+		{":020000000102FB\n:020010000304E7\n", 2, []int{2}},
+		// Garbage after EOF
+		{":020000000102FB\n:00000001FF\n:020010000304E7\ngarbage\n", 1, []int{3, 4}},
+	}
+
+	for i, tc := range cases {
+		t.Logf("Case %d", i)
+
+		s := NewScanner(strings.NewReader(tc.input))
+		s.SetMode(ScanLenient)
+
+		segments := 0
+		for s.Scan() {
+			segments++
+		}
+		if err := s.Err(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		if segments != tc.segments {
+			t.Errorf("segment count mismatch: expected=%d, actual=%d", tc.segments, segments)
+		}
+
+		diagnostics := s.Diagnostics()
+		if len(diagnostics) != len(tc.lines) {
+			t.Errorf("diagnostic mismatch: expected lines %v, actual=%v", tc.lines, diagnostics)
+			continue
+		}
+		for j, d := range diagnostics {
+			if d.Line != tc.lines[j] {
+				t.Errorf("    [diagnostic %d] line mismatch: expected=%d, actual=%d", j, tc.lines[j], d.Line)
+			}
+		}
+	}
+}
+
+func TestScannerWraparound(t *testing.T) {
+	const (
+		segmentWrap = `:020000021000EC
+:04FFFE0001020304F5
+:00000001FF`
+		linearWrap = `:02000004FFFFFC
+:04FFFE0001020304F5
+:00000001FF`
+		linearStraddle = `:020000040001F9
+:04FFFE0001020304F5
+:00000001FF`
+	)
+
+	var cases = []struct {
+		input    string
+		mode     ScanMode
+		segments []Segment
+	}{
+		{segmentWrap, 0, []Segment{
+			{0x0001FFFE, decodeHex("0102")},
+			{0x00010000, decodeHex("0304")},
+		}},
+		{segmentWrap, ScanFlatAddressing, []Segment{
+			{0x0001FFFE, decodeHex("01020304")},
+		}},
+		{linearWrap, 0, []Segment{
+			{0xFFFFFFFE, decodeHex("0102")},
+			{0x00000000, decodeHex("0304")},
+		}},
+		{linearStraddle, 0, []Segment{
+			{0x0001FFFE, decodeHex("01020304")},
+		}},
+	}
+
+	for i, tc := range cases {
+		t.Logf("Case %d", i)
+
+		s := NewScanner(strings.NewReader(tc.input))
+		s.SetMode(tc.mode)
+
+		segments := make([]Segment, 0)
+		for s.Scan() {
+			segments = append(segments, s.Segment())
+			if s.Line() != 2 {
+				t.Errorf("line mismatch: expected=2, actual=%d", s.Line())
+			}
+		}
+		if err := s.Err(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+
+		if len(segments) != len(tc.segments) {
+			t.Errorf("segment length mismatch: expected=%d, actual=%d", len(tc.segments), len(segments))
+			continue
+		}
+		for j := range segments {
+			if segments[j].Address != tc.segments[j].Address {
+				t.Errorf("    [segment %d] address mismatch: expected=0x%08X, actual=0x%08X", j, tc.segments[j].Address, segments[j].Address)
+			}
+			if !bytes.Equal(segments[j].Data, tc.segments[j].Data) {
+				t.Errorf("    [segment %d] data mismatch: expected=%X, actual=%X", j, tc.segments[j].Data, segments[j].Data)
+			}
+		}
+
+		w := s.Warnings()
+		if len(w) != 1 || w[0].Line != 2 || !errors.Is(w[0], ErrSegmentBoundary) {
+			t.Errorf("expected segment boundary warning on line 2 but got %v", w)
+		}
+		if d := s.Diagnostics(); len(d) != 0 {
+			t.Errorf("unexpected diagnostics: %v", d)
+		}
+	}
+}
+
+func TestScannerEOFModes(t *testing.T) {
+	const (
+		concatenated = `:020000040001F9
+:020000000102FB
+:00000001FF
+:02001000AABB89
+:00000001FF
+`
+		trailing = `:020000000102FB
+:00000001FF
+:02001000AABB89
+`
+		missing = `:020000000102FB
+`
+	)
+
+	var cases = []struct {
+		input    string
+		mode     ScanMode
+		cause    error
+		segments []Segment
+		warnings int
+	}{
+		// Concatenated files stop at the first EOF by default
+		{concatenated, 0, nil, []Segment{{0x00010000, decodeHex("0102")}}, 0},
+		// The address base is reset for the following section
+		{concatenated, ScanMultipleEOF, nil, []Segment{
+			{0x00010000, decodeHex("0102")},
+			{0x00000010, decodeHex("AABB")},
+		}, 0},
+		{trailing, 0, nil, []Segment{{0x0000, decodeHex("0102")}}, 0},
+		{trailing, ScanStrictEOF, ErrAfterEOF, []Segment{{0x0000, decodeHex("0102")}}, 0},
+		{missing, 0, ErrMissingEOF, []Segment{{0x0000, decodeHex("0102")}}, 0},
+		{missing, ScanMultipleEOF, ErrMissingEOF, []Segment{{0x0000, decodeHex("0102")}}, 0},
+		{missing, ScanAllowMissingEOF, nil, []Segment{{0x0000, decodeHex("0102")}}, 1},
+	}
+
+	for i, tc := range cases {
+		t.Logf("Case %d", i)
+
+		s := NewScanner(strings.NewReader(tc.input))
+		s.SetMode(tc.mode)
+
+		segments := make([]Segment, 0)
+		for s.Scan() {
+			segments = append(segments, s.Segment())
+		}
+
+		if tc.cause != nil {
+			if !errors.Is(s.Err(), tc.cause) {
+				t.Errorf("expected %v but got %v", tc.cause, s.Err())
+			}
+		} else if s.Err() != nil {
+			t.Errorf("unexpected error: %v", s.Err())
+		}
+
+		if len(segments) != len(tc.segments) {
+			t.Errorf("segment length mismatch: expected=%d, actual=%d", len(tc.segments), len(segments))
+		} else {
+			for j := range segments {
+				if segments[j].Address != tc.segments[j].Address {
+					t.Errorf("    [segment %d] address mismatch: expected=0x%08X, actual=0x%08X", j, tc.segments[j].Address, segments[j].Address)
+				}
+			}
+		}
+
+		if len(s.Warnings()) != tc.warnings {
+			t.Errorf("warning count mismatch: expected=%d, actual=%d", tc.warnings, len(s.Warnings()))
+		}
+		if len(s.Diagnostics()) != 0 {
+			t.Errorf("unexpected diagnostics: %v", s.Diagnostics())
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"bufio"
+	"io"
+)
+
+// DefaultMaxLineLength is the longest line accepted by the scanners unless
+// configured otherwise.
+const DefaultMaxLineLength = bufio.MaxScanTokenSize
+
+// lineReader splits its input into lines like bufio.ScanLines while keeping
+// track of line numbers and byte offsets. Unlike bufio.Scanner it can skip
+// over lines longer than its limit and carry on with the next one.
+type lineReader struct {
+	reader *bufio.Reader
+	max    int
+	err    error
+
+	text    []byte
+	tooLong bool
+
+	line       int
+	offset     int64
+	lineOffset int64
+}
+
+func newLineReader(r io.Reader) *lineReader {
+	return &lineReader{
+		reader: bufio.NewReader(r),
+		max:    DefaultMaxLineLength,
+	}
+}
+
+// setMax sets the longest line accepted. Limits below 1 are treated as 1.
+func (lr *lineReader) setMax(max int) {
+	if max < 1 {
+		max = 1
+	}
+	lr.max = max
+}
+
+// next reads the next line, returning false at the end of the input or on a
+// read error. Lines longer than the limit are truncated to it and flagged as
+// too long.
+func (lr *lineReader) next() bool {
+	if lr.err != nil {
+		return false
+	}
+
+	lr.text = lr.text[:0]
+	lr.tooLong = false
+	lr.lineOffset = lr.offset
+
+	var n int
+	for {
+		chunk, err := lr.reader.ReadSlice('\n')
+		n += len(chunk)
+		lr.offset += int64(len(chunk))
+
+		// Keep at most the limit plus room for a \r\n line ending
+		keep := lr.max + 2 - len(lr.text)
+		if keep < len(chunk) {
+			if keep > 0 {
+				lr.text = append(lr.text, chunk[:keep]...)
+			}
+			lr.tooLong = true
+		} else {
+			lr.text = append(lr.text, chunk...)
+		}
+
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			if err != io.EOF {
+				lr.err = err
+				return false
+			}
+			if n == 0 {
+				return false
+			}
+		}
+		break
+	}
+
+	lr.line++
+
+	// Drop the line ending
+	if len(lr.text) > 0 && lr.text[len(lr.text)-1] == '\n' {
+		lr.text = lr.text[:len(lr.text)-1]
+	}
+	if len(lr.text) > 0 && lr.text[len(lr.text)-1] == '\r' {
+		lr.text = lr.text[:len(lr.text)-1]
+	}
+	if len(lr.text) > lr.max {
+		lr.text = lr.text[:lr.max]
+		lr.tooLong = true
+	}
+
+	return true
+}
+
+// Err returns the first read error other than io.EOF.
+func (lr *lineReader) Err() error {
+	return lr.err
+}
+
+// lineTooLongError returns the error reported for the current line when it is
+// too long.
+func (lr *lineReader) lineTooLongError() *ParseError {
+	return &ParseError{
+		Line:   lr.line,
+		Column: lr.max + 1,
+		Text:   string(lr.text),
+		Err:    ErrLineTooLong,
+	}
+}
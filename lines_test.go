@@ -0,0 +1,89 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLineReader(t *testing.T) {
+	input := "abc\r\n" + strings.Repeat("x", 10000) + "\n\nlast"
+
+	var (
+		lr    = newLineReader(strings.NewReader(input))
+		texts = []string{"abc", strings.Repeat("x", 10000), "", "last"}
+		i     int
+	)
+	for lr.next() {
+		if i >= len(texts) {
+			t.Fatalf("unexpected line %d", lr.line)
+		}
+		if string(lr.text) != texts[i] {
+			t.Errorf("[line %d] text mismatch: expected %d bytes, actual %d bytes", lr.line, len(texts[i]), len(lr.text))
+		}
+		if lr.tooLong {
+			t.Errorf("[line %d] unexpectedly too long", lr.line)
+		}
+		i++
+	}
+	if lr.Err() != nil {
+		t.Fatalf("unexpected error: %v", lr.Err())
+	}
+	if i != len(texts) {
+		t.Errorf("line count mismatch: expected=%d, actual=%d", len(texts), i)
+	}
+}
+
+func TestScannerLineTooLong(t *testing.T) {
+	input := ":020000000102FB\n" + strings.Repeat("#", 100000) + "\n:020010000304E7\n:00000001FF\n"
+
+	// Strict mode reports a positioned error
+	s := NewScanner(strings.NewReader(input))
+	for s.Scan() {
+	}
+	var perr *ParseError
+	if !errors.As(s.Err(), &perr) || !errors.Is(perr, ErrLineTooLong) {
+		t.Fatalf("expected line too long error but got %v", s.Err())
+	}
+	if perr.Line != 2 || perr.Column != DefaultMaxLineLength+1 {
+		t.Errorf("position mismatch: expected=2:%d, actual=%d:%d", DefaultMaxLineLength+1, perr.Line, perr.Column)
+	}
+
+	// Lenient mode skips the line
+	s = NewScanner(strings.NewReader(input))
+	s.SetMode(ScanLenient)
+	segments := 0
+	for s.Scan() {
+		segments++
+	}
+	if s.Err() != nil {
+		t.Fatalf("unexpected error: %v", s.Err())
+	}
+	if segments != 2 {
+		t.Errorf("expected 2 segments but got %d", segments)
+	}
+	if d := s.Diagnostics(); len(d) != 1 || d[0].Line != 2 {
+		t.Errorf("unexpected diagnostics %v", d)
+	}
+
+	// A larger limit accepts the line, which then fails on its start code
+	s = NewScanner(strings.NewReader(input))
+	s.SetMaxLineLength(200000)
+	for s.Scan() {
+	}
+	if !errors.Is(s.Err(), ErrStartCode) {
+		t.Errorf("expected start code error but got %v", s.Err())
+	}
+
+	// A negative limit is treated as 1 rather than panicking
+	s = NewScanner(strings.NewReader(input))
+	s.SetMaxLineLength(-5)
+	for s.Scan() {
+	}
+	if !errors.As(s.Err(), &perr) || !errors.Is(perr, ErrLineTooLong) || perr.Line != 1 || perr.Column != 2 {
+		t.Errorf("expected line too long error at 1:2 but got %v", s.Err())
+	}
+}
@@ -0,0 +1,284 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// Region is a named area of a device's memory such as flash, option bytes,
+// EEPROM or OTP memory.
+type Region struct {
+	Name  string
+	Start uint32
+	Size  uint64
+	// PageSize is the erase granularity, 0 if the region isn't erased in
+	// pages.
+	PageSize uint32
+	// ReadOnly marks regions that images must not write to.
+	ReadOnly bool
+}
+
+// Range returns the addresses of the region. It must not be called on an
+// empty region.
+func (r *Region) Range() Range {
+	return Range{r.Start, uint32(uint64(r.Start) + r.Size - 1)}
+}
+
+// UnmarshalJSON decodes a region from an object with the keys name, start,
+// size, page_size and read_only. Addresses and sizes may be numbers or strings
+// in decimal or hex with a 0x prefix. Unknown keys are an error.
+func (r *Region) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Name     string      `json:"name"`
+		Start    jsonAddress `json:"start"`
+		Size     jsonAddress `json:"size"`
+		PageSize jsonAddress `json:"page_size"`
+		ReadOnly bool        `json:"read_only"`
+	}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	if err := d.Decode(&v); err != nil {
+		return err
+	}
+	if v.Start >= addressSpaceSize || v.PageSize >= addressSpaceSize {
+		return fmt.Errorf("region %q is outside the 32-bit address space", v.Name)
+	}
+	*r = Region{
+		Name:     v.Name,
+		Start:    uint32(v.Start),
+		Size:     uint64(v.Size),
+		PageSize: uint32(v.PageSize),
+		ReadOnly: v.ReadOnly,
+	}
+	return nil
+}
+
+// jsonAddress is a JSON number or string holding an address or size.
+type jsonAddress uint64
+
+func (a *jsonAddress) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+	v, err := strconv.ParseUint(s, 0, 64)
+	if err != nil {
+		return fmt.Errorf("invalid address %s", data)
+	}
+	*a = jsonAddress(v)
+	return nil
+}
+
+// MemoryMap describes the memory regions of a target device.
+type MemoryMap struct {
+	Name    string   `json:"name"`
+	Regions []Region `json:"regions"`
+}
+
+// ReadMemoryMap reads a memory map in JSON format from r and validates it.
+func ReadMemoryMap(r io.Reader) (*MemoryMap, error) {
+	m := new(MemoryMap)
+	d := json.NewDecoder(r)
+	d.DisallowUnknownFields()
+	if err := d.Decode(m); err != nil {
+		return nil, err
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ReadMemoryMapFile reads a memory map in JSON format from a file.
+func ReadMemoryMapFile(filename string) (*MemoryMap, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadMemoryMap(f)
+}
+
+// Validate checks that the regions are named, not empty, within the 32-bit
+// address space, aligned to their page size and don't overlap each other. It
+// sorts the regions by address.
+func (m *MemoryMap) Validate() error {
+	for _, r := range m.Regions {
+		switch {
+		case r.Name == "":
+			return fmt.Errorf("region at 0x%08X has no name", r.Start)
+		case r.Size == 0:
+			return fmt.Errorf("region %q is empty", r.Name)
+		case uint64(r.Start)+r.Size > addressSpaceSize:
+			return fmt.Errorf("region %q extends past the 32-bit address space", r.Name)
+		case r.PageSize != 0 && (r.Start%r.PageSize != 0 || r.Size%uint64(r.PageSize) != 0):
+			return fmt.Errorf("region %q is not aligned to its page size of %d bytes", r.Name, r.PageSize)
+		}
+	}
+
+	sort.SliceStable(m.Regions, func(i, j int) bool {
+		return m.Regions[i].Start < m.Regions[j].Start
+	})
+	for i := 1; i < len(m.Regions); i++ {
+		if a, b := &m.Regions[i-1], &m.Regions[i]; a.Range().Overlaps(b.Range()) {
+			return fmt.Errorf("region %q overlaps region %q", b.Name, a.Name)
+		}
+	}
+	return nil
+}
+
+// ViolationKind is the way an image breaks the rules of a memory map.
+type ViolationKind int
+
+const (
+	// ViolationOutside is data outside of all regions.
+	ViolationOutside ViolationKind = iota
+	// ViolationReadOnly is data in a read-only region.
+	ViolationReadOnly
+	// ViolationOverflow is data running past the end of the region it
+	// starts in, into unmapped addresses or the following region, such as an
+	// image too big for the flash memory.
+	ViolationOverflow
+)
+
+var violationKindNames = []string{
+	ViolationOutside:  "outside",
+	ViolationReadOnly: "read-only",
+	ViolationOverflow: "overflow",
+}
+
+func (k ViolationKind) String() string {
+	if k < 0 || int(k) >= len(violationKindNames) {
+		return fmt.Sprintf("ViolationKind(%d)", int(k))
+	}
+	return violationKindNames[k]
+}
+
+// Violation is a range of an image's data that breaks the rules of a memory
+// map.
+type Violation struct {
+	Kind  ViolationKind
+	Range Range
+	// Region is the name of the read-only or overflowing region, empty for
+	// data outside of all regions.
+	Region string
+}
+
+func (v Violation) String() string {
+	switch v.Kind {
+	case ViolationReadOnly:
+		return fmt.Sprintf("data at %v is in read-only region %s", v.Range, v.Region)
+	case ViolationOverflow:
+		return fmt.Sprintf("data at %v overflows region %s", v.Range, v.Region)
+	}
+	return fmt.Sprintf("data at %v is outside of all regions", v.Range)
+}
+
+// Check returns the ranges of the image's data that lie outside of all regions,
+// in read-only regions or run past the end of the region they start in, into
+// unmapped addresses or another region, in ascending order. The regions must
+// have been validated.
+func (m *MemoryMap) Check(img *Image) []Violation {
+	violations := make([]Violation, 0)
+	for _, data := range img.Ranges() {
+		var (
+			next  = uint64(data.Low)
+			start *Region // region the data up to next started in
+		)
+		for next <= uint64(data.High) {
+			region := m.regionAfter(uint32(next))
+			if region != nil && uint64(region.Start) <= next {
+				high := minAddress(region.Range().High, data.High)
+				if start == nil {
+					start = region
+				} else if start != region {
+					violations = append(violations, Violation{ViolationOverflow, Range{uint32(next), high}, start.Name})
+				}
+				if region.ReadOnly {
+					violations = append(violations, Violation{ViolationReadOnly, Range{uint32(next), high}, region.Name})
+				}
+				next = uint64(high) + 1
+				continue
+			}
+
+			// Data outside of all regions up to the next region
+			high := data.High
+			if region != nil && region.Start <= data.High {
+				high = region.Start - 1
+			}
+			v := Violation{ViolationOutside, Range{uint32(next), high}, ""}
+			if start != nil {
+				v.Kind, v.Region = ViolationOverflow, start.Name
+			}
+			violations = append(violations, v)
+			next, start = uint64(high)+1, nil
+		}
+	}
+	return violations
+}
+
+// regionAfter returns the region containing address or the first region after
+// it, nil if there is none.
+func (m *MemoryMap) regionAfter(address uint32) *Region {
+	i := sort.Search(len(m.Regions), func(i int) bool {
+		return m.Regions[i].Range().High >= address
+	})
+	if i == len(m.Regions) {
+		return nil
+	}
+	return &m.Regions[i]
+}
+
+// RegionUsage is how much of a region an image uses.
+type RegionUsage struct {
+	Region *Region
+	// Used is the number of bytes of data in the region.
+	Used uint64
+	// Pages is the number of pages holding data, 0 if the region has no page
+	// size.
+	Pages int
+}
+
+// Usage returns how much of each region the image uses, in the order of the
+// regions.
+func (m *MemoryMap) Usage(img *Image) []RegionUsage {
+	usage := make([]RegionUsage, len(m.Regions))
+	for i := range m.Regions {
+		var (
+			region = &m.Regions[i]
+			u      = RegionUsage{Region: region}
+			page   = int64(-1)
+		)
+		inside, _ := img.split(region.Range())
+		for _, s := range inside {
+			r := s.Range()
+			u.Used += uint64(r.High-r.Low) + 1
+			if region.PageSize == 0 {
+				continue
+			}
+			var (
+				first = int64((r.Low - region.Start) / region.PageSize)
+				last  = int64((r.High - region.Start) / region.PageSize)
+			)
+			if first == page {
+				first++
+			}
+			if last >= first {
+				u.Pages += int(last - first + 1)
+			}
+			page = last
+		}
+		usage[i] = u
+	}
+	return usage
+}
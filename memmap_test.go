@@ -0,0 +1,119 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"strings"
+	"testing"
+)
+
+const testMemoryMap = `{
+	"name": "test",
+	"regions": [
+		{"name": "option", "start": "0x1FFF0000", "size": 16, "read_only": true},
+		{"name": "flash", "start": "0x08000000", "size": "0x1000", "page_size": "0x400"},
+		{"name": "ram", "start": 536870912, "size": "0x2000"}
+	]
+}`
+
+func TestReadMemoryMap(t *testing.T) {
+	m, err := ReadMemoryMap(strings.NewReader(testMemoryMap))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(m.Regions) != 3 {
+		t.Fatalf("expected 3 regions but got %d", len(m.Regions))
+	}
+	flash := m.Regions[0]
+	if flash.Name != "flash" || flash.Range() != (Range{0x08000000, 0x08000FFF}) || flash.PageSize != 0x400 || flash.ReadOnly {
+		t.Errorf("region mismatch: %+v", flash)
+	}
+	if m.Regions[1].Name != "option" || !m.Regions[1].ReadOnly {
+		t.Errorf("region mismatch: %+v", m.Regions[1])
+	}
+}
+
+func TestMemoryMapValidate(t *testing.T) {
+	var cases = []string{
+		`{"regions": [{"name": "a", "start": 0, "size": 0}]}`,
+		`{"regions": [{"start": 0, "size": 1}]}`,
+		`{"regions": [{"name": "a", "start": "0xFFFFFFFF", "size": 2}]}`,
+		`{"regions": [{"name": "a", "start": 0, "size": 16}, {"name": "b", "start": 15, "size": 16}]}`,
+		`{"regions": [{"name": "a", "start": 512, "size": 1024, "page_size": 1024}]}`,
+		`{"regions": [{"name": "a", "start": "nope", "size": 1}]}`,
+		`{"regions": [{"name": "a", "start": 0, "size": 1, "writable": true}]}`,
+	}
+
+	for i, tc := range cases {
+		t.Logf("Case %d", i)
+		if _, err := ReadMemoryMap(strings.NewReader(tc)); err == nil {
+			t.Errorf("expected error")
+		}
+	}
+}
+
+func TestMemoryMapCheck(t *testing.T) {
+	m, err := ReadMemoryMap(strings.NewReader(testMemoryMap))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var img Image
+	img.WriteAt(make([]byte, 0x10), 0x07FFFFF8)
+	img.WriteAt(make([]byte, 0x10), 0x08000FF8)
+	img.WriteAt(make([]byte, 0x10), 0x1FFEFFF8)
+	img.WriteAt(make([]byte, 0x10), 0x20001FF8)
+
+	expected := []Violation{
+		{ViolationOutside, Range{0x07FFFFF8, 0x07FFFFFF}, ""},
+		{ViolationOverflow, Range{0x08001000, 0x08001007}, "flash"},
+		{ViolationOutside, Range{0x1FFEFFF8, 0x1FFEFFFF}, ""},
+		{ViolationReadOnly, Range{0x1FFF0000, 0x1FFF0007}, "option"},
+		{ViolationOverflow, Range{0x20002000, 0x20002007}, "ram"},
+	}
+	violations := m.Check(&img)
+	if len(violations) != len(expected) {
+		t.Fatalf("violation mismatch: expected=%v, actual=%v", expected, violations)
+	}
+	for i := range expected {
+		if violations[i] != expected[i] {
+			t.Errorf("violation mismatch: expected=%v, actual=%v", expected[i], violations[i])
+		}
+	}
+
+	usage := m.Usage(&img)
+	if u := usage[0]; u.Region.Name != "flash" || u.Used != 0x10 || u.Pages != 2 {
+		t.Errorf("usage mismatch: expected=flash 16 bytes 2 pages, actual=%s %d bytes %d pages", u.Region.Name, u.Used, u.Pages)
+	}
+}
+
+func TestMemoryMapCheckAdjacentRegions(t *testing.T) {
+	m, err := ReadMemoryMap(strings.NewReader(`{"regions": [
+		{"name": "flash", "start": 0, "size": "0x100"},
+		{"name": "eeprom", "start": "0x100", "size": "0x100"},
+		{"name": "otp", "start": "0x200", "size": "0x10", "read_only": true}
+	]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var img Image
+	img.WriteAt(make([]byte, 0x120), 0x0000)
+	img.WriteAt(make([]byte, 0x10), 0x01F8)
+
+	expected := []Violation{
+		{ViolationOverflow, Range{0x0100, 0x011F}, "flash"},
+		{ViolationOverflow, Range{0x0200, 0x0207}, "eeprom"},
+		{ViolationReadOnly, Range{0x0200, 0x0207}, "otp"},
+	}
+	violations := m.Check(&img)
+	if len(violations) != len(expected) {
+		t.Fatalf("violation mismatch: expected=%v, actual=%v", expected, violations)
+	}
+	for i := range expected {
+		if violations[i] != expected[i] {
+			t.Errorf("violation mismatch: expected=%v, actual=%v", expected[i], violations[i])
+		}
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// MergeInput is one of the images combined by Merge.
+type MergeInput struct {
+	// Name identifies the input in the provenance and in errors, usually by
+	// its file name.
+	Name string
+	// Image is the input's data.
+	Image *Image
+	// Offset is added to every address of the input.
+	Offset int64
+	// Priority decides which input's data is kept where inputs overlap. Data
+	// of a higher priority input replaces data of a lower one, while the
+	// overlap policy applies to data of equal priority.
+	Priority int
+}
+
+// Provenance records which input the data of an address range came from.
+type Provenance struct {
+	Range  Range
+	Source string
+}
+
+// Merge combines the inputs into a single image and returns it along with the
+// provenance of every occupied address range, in ascending order. Inputs of
+// equal priority are loaded in the given order and their overlaps are handled
+// according to the policy; errors for which IsOverlapError returns true name
+// both inputs. The start address is taken from the highest priority input that
+// has one.
+func Merge(policy OverlapPolicy, inputs ...MergeInput) (*Image, []Provenance, error) {
+	// Load the inputs from the highest to the lowest priority so lower
+	// priority data only fills in the holes
+	sorted := make([]MergeInput, len(inputs))
+	copy(sorted, inputs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority > sorted[j].Priority
+	})
+
+	var (
+		img    = new(Image)
+		loader = &overlapLoader{img: img, policy: policy}
+		start  *MergeInput
+	)
+	for i, in := range sorted {
+		org := origin{source: in.Name, priority: in.Priority}
+		for _, s := range in.Image.segments {
+			address := int64(s.Address) + in.Offset
+			if address < 0 || address+int64(len(s.Data)) > addressSpaceSize {
+				return nil, nil, fmt.Errorf("data at 0x%08X from %s is moved outside the 32-bit address space by offset %d", s.Address, in.Name, in.Offset)
+			}
+			if err := loader.load(Segment{uint32(address), s.Data}, org); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		switch {
+		case in.Image.Start == nil:
+		case start == nil, start.Priority == in.Priority && policy == OverlapLastWins:
+			start = &sorted[i]
+		case start.Priority == in.Priority && *start.Image.Start != *in.Image.Start && policy != OverlapFirstWins:
+			return nil, nil, fmt.Errorf("start address %v from %s conflicts with %v from %s", in.Image.Start, in.Name, start.Image.Start, start.Name)
+		}
+	}
+	if start != nil {
+		s := *start.Image.Start
+		img.Start = &s
+	}
+
+	// Join adjacent ranges from the same input
+	provenance := make([]Provenance, 0, len(loader.owners))
+	for _, o := range loader.owners {
+		if n := len(provenance); n > 0 && provenance[n-1].Source == o.source && uint64(provenance[n-1].Range.High)+1 == uint64(o.Low) {
+			provenance[n-1].Range.High = o.High
+			continue
+		}
+		provenance = append(provenance, Provenance{o.Range, o.source})
+	}
+
+	return img, provenance, nil
+}
+
+// WriteMap writes a map of the provenance with one address range per line.
+func WriteMap(w io.Writer, provenance []Provenance) error {
+	for _, p := range provenance {
+		_, err := fmt.Fprintf(w, "%v  %10d  %s\n", p.Range, p.Range.Size(), p.Source)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	var boot, app, config Image
+	boot.WriteAt(decodeHex("01020304"), 0x0000)
+	boot.Start = NewStartLinearAddress(0x0000)
+	app.WriteAt(decodeHex("A1A2A3A4A5A6"), 0x0000)
+	app.Start = NewStartLinearAddress(0x1000)
+	config.WriteAt(decodeHex("C1C2"), 0x0000)
+
+	img, provenance, err := Merge(OverlapReject,
+		MergeInput{Name: "app.hex", Image: &app, Offset: 0x0002},
+		MergeInput{Name: "boot.hex", Image: &boot, Priority: 1},
+		MergeInput{Name: "config.hex", Image: &config, Offset: 0x0008},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data := make([]byte, 10)
+	if _, err := img.ReadAt(data, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(data, decodeHex("01020304A3A4A5A6C1C2")) {
+		t.Errorf("data mismatch: expected=01020304A3A4A5A6C1C2, actual=%X", data)
+	}
+	if img.Start == nil || *img.Start != *boot.Start {
+		t.Errorf("expected start address %v but got %v", boot.Start, img.Start)
+	}
+
+	expected := []Provenance{
+		{Range{0x0000, 0x0003}, "boot.hex"},
+		{Range{0x0004, 0x0007}, "app.hex"},
+		{Range{0x0008, 0x0009}, "config.hex"},
+	}
+	if len(provenance) != len(expected) {
+		t.Fatalf("provenance mismatch: expected=%v, actual=%v", expected, provenance)
+	}
+	for i := range expected {
+		if provenance[i] != expected[i] {
+			t.Errorf("provenance mismatch: expected=%v, actual=%v", expected[i], provenance[i])
+		}
+	}
+}
+
+func TestMergeOverlap(t *testing.T) {
+	var a, b Image
+	a.WriteAt(decodeHex("01020304"), 0x1000)
+	b.WriteAt(decodeHex("AABB"), 0x0003)
+
+	_, _, err := Merge(OverlapReject,
+		MergeInput{Name: "a.hex", Image: &a},
+		MergeInput{Name: "b.hex", Image: &b, Offset: 0x1000},
+	)
+	oerr, ok := err.(*OverlapError)
+	if !ok {
+		t.Fatalf("expected overlap error but got %v", err)
+	}
+	if oerr.Range != (Range{0x1003, 0x1003}) || oerr.FirstSource != "a.hex" || oerr.SecondSource != "b.hex" {
+		t.Errorf("overlap mismatch: expected=0x00001003-0x00001003 a.hex b.hex, actual=%v %s %s", oerr.Range, oerr.FirstSource, oerr.SecondSource)
+	}
+
+	img, provenance, err := Merge(OverlapLastWins,
+		MergeInput{Name: "a.hex", Image: &a},
+		MergeInput{Name: "b.hex", Image: &b, Offset: 0x1000},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data := make([]byte, 5)
+	img.ReadAt(data, 0x1000)
+	if !bytes.Equal(data, decodeHex("010203AABB")) {
+		t.Errorf("data mismatch: expected=010203AABB, actual=%X", data)
+	}
+	if len(provenance) != 2 || provenance[1] != (Provenance{Range{0x1003, 0x1004}, "b.hex"}) {
+		t.Errorf("provenance mismatch: actual=%v", provenance)
+	}
+}
+
+func TestMergeOutOfRange(t *testing.T) {
+	var a Image
+	a.WriteAt(decodeHex("0102"), 0x0001)
+
+	cases := []int64{-2, 0xFFFFFFFF}
+	for i, offset := range cases {
+		t.Logf("Case %d", i)
+		if _, _, err := Merge(OverlapReject, MergeInput{Name: "a.hex", Image: &a, Offset: offset}); err == nil {
+			t.Errorf("expected error for offset %d", offset)
+		}
+	}
+}
+
+func TestMergeMixedPriorities(t *testing.T) {
+	var hi, a, b Image
+	hi.WriteAt(decodeHex("0101"), 0x0000)
+	a.WriteAt(decodeHex("02020202"), 0x0002)
+	b.WriteAt(decodeHex("030303030303"), 0x0000)
+
+	// b replaces the data of a but not of hi, which has a higher priority
+	img, provenance, err := Merge(OverlapLastWins,
+		MergeInput{Name: "hi.hex", Image: &hi, Priority: 1},
+		MergeInput{Name: "a.hex", Image: &a},
+		MergeInput{Name: "b.hex", Image: &b},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data := make([]byte, 6)
+	img.ReadAt(data, 0)
+	if !bytes.Equal(data, decodeHex("010103030303")) {
+		t.Errorf("data mismatch: expected=010103030303, actual=%X", data)
+	}
+	expected := []Provenance{
+		{Range{0x0000, 0x0001}, "hi.hex"},
+		{Range{0x0002, 0x0005}, "b.hex"},
+	}
+	if len(provenance) != len(expected) || provenance[0] != expected[0] || provenance[1] != expected[1] {
+		t.Errorf("provenance mismatch: expected=%v, actual=%v", expected, provenance)
+	}
+
+	// First wins keeps a where it doesn't collide with hi
+	img, provenance, err = Merge(OverlapFirstWins,
+		MergeInput{Name: "hi.hex", Image: &hi, Priority: 1},
+		MergeInput{Name: "a.hex", Image: &a},
+		MergeInput{Name: "b.hex", Image: &b},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	img.ReadAt(data, 0)
+	if !bytes.Equal(data, decodeHex("010102020202")) {
+		t.Errorf("data mismatch: expected=010102020202, actual=%X", data)
+	}
+	if len(provenance) != 2 || provenance[1] != (Provenance{Range{0x0002, 0x0005}, "a.hex"}) {
+		t.Errorf("provenance mismatch: actual=%v", provenance)
+	}
+}
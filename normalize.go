@@ -0,0 +1,49 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"bytes"
+	"io"
+)
+
+// Normalize reads Intel HEX from r and writes it to w in canonical form: the
+// data sorted by address with adjacent records coalesced, split into
+// DefaultRecordSize records at 64 KiB boundaries, upper case hex digits, \n
+// line endings and extended linear address records only where the address
+// base changes. Files describing the same memory contents and start address
+// normalize to the same text.
+func Normalize(w io.Writer, r io.Reader) error {
+	img, err := ReadImage(NewScanner(r))
+	if err != nil {
+		return err
+	}
+	return img.Write(w)
+}
+
+// IsCanonical returns true if the Intel HEX text in data is already in the
+// canonical form written by Normalize.
+func IsCanonical(data []byte) (bool, error) {
+	img, err := ReadImage(NewScanner(bytes.NewReader(data)))
+	if err != nil {
+		return false, err
+	}
+	return isCanonical(img, data)
+}
+
+// isCanonical returns true if data, which img was read from, is the canonical
+// form of img.
+func isCanonical(img *Image, data []byte) (bool, error) {
+	var buf bytes.Buffer
+	if err := img.Write(&buf); err != nil {
+		return false, err
+	}
+	return bytes.Equal(buf.Bytes(), data), nil
+}
+
+// Equal returns true if the images have the same memory contents and start
+// address, regardless of how their data is split into segments.
+func (img *Image) Equal(other *Image) bool {
+	return Compare(img, other).Equal()
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	// Out of order, lower case, CRLF, split records and a redundant address
+	// record
+	input := ":020000040000fa\r\n" +
+		":020002000304f5\r\n" +
+		":020000040000fa\r\n" +
+		":020000000102fb\r\n" +
+		":00000001ff\r\n"
+	expected := ":0400000001020304F2\n" +
+		":00000001FF\n"
+
+	var buf bytes.Buffer
+	if err := Normalize(&buf, strings.NewReader(input)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.String() != expected {
+		t.Errorf("output mismatch: expected=%q, actual=%q", expected, buf.String())
+	}
+
+	var cases = []struct {
+		text      string
+		canonical bool
+	}{
+		{input, false},
+		{expected, true},
+	}
+	for i, tc := range cases {
+		t.Logf("Case %d", i)
+		canonical, err := IsCanonical([]byte(tc.text))
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		} else if canonical != tc.canonical {
+			t.Errorf("canonical mismatch: expected=%t, actual=%t", tc.canonical, canonical)
+		}
+	}
+}
+
+func TestImageEqual(t *testing.T) {
+	a, err := ReadImage(NewScanner(strings.NewReader(":020002000304F5\n:020000000102FB\n:00000001FF\n")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := ReadImage(NewScanner(strings.NewReader(":0400000001020304F2\n:00000001FF\n")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !a.Equal(b) {
+		t.Errorf("expected images to be equal")
+	}
+
+	b.Start = NewStartLinearAddress(0)
+	if a.Equal(b) {
+		t.Errorf("expected images with different start addresses to differ")
+	}
+}
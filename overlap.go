@@ -0,0 +1,222 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// OverlapPolicy selects what happens when data is loaded at addresses that
+// already hold data.
+type OverlapPolicy int
+
+const (
+	// OverlapReject fails on any overlap.
+	OverlapReject OverlapPolicy = iota
+	// OverlapFirstWins keeps the data that was loaded first.
+	OverlapFirstWins
+	// OverlapLastWins overwrites earlier data with later data.
+	OverlapLastWins
+	// OverlapRejectDiffering fails only if the overlapping bytes differ.
+	OverlapRejectDiffering
+)
+
+var overlapPolicyNames = []string{
+	OverlapReject:          "error",
+	OverlapFirstWins:       "first",
+	OverlapLastWins:        "last",
+	OverlapRejectDiffering: "differ",
+}
+
+func (p OverlapPolicy) String() string {
+	if p < 0 || int(p) >= len(overlapPolicyNames) {
+		return fmt.Sprintf("OverlapPolicy(%d)", int(p))
+	}
+	return overlapPolicyNames[p]
+}
+
+// ParseOverlapPolicy returns the policy with the given name as returned by
+// OverlapPolicy.String.
+func ParseOverlapPolicy(name string) (OverlapPolicy, error) {
+	for p, n := range overlapPolicyNames {
+		if n == name {
+			return OverlapPolicy(p), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown overlap policy %q", name)
+}
+
+// OverlapError reports data loaded at addresses that already held data.
+type OverlapError struct {
+	// Range is the overlapping address range.
+	Range Range
+	// FirstLine and SecondLine are the line numbers of the records that
+	// loaded the data first and second, or 0 if unknown.
+	FirstLine  int
+	SecondLine int
+	// FirstSource and SecondSource name the inputs the data came from when
+	// merging several inputs.
+	FirstSource  string
+	SecondSource string
+}
+
+func (err *OverlapError) Error() string {
+	return fmt.Sprintf("data at %v from %s overlaps data from %s", err.Range,
+		describeOrigin(err.SecondSource, err.SecondLine),
+		describeOrigin(err.FirstSource, err.FirstLine))
+}
+
+func describeOrigin(source string, line int) string {
+	switch {
+	case source != "" && line != 0:
+		return fmt.Sprintf("%s line %d", source, line)
+	case source != "":
+		return source
+	case line != 0:
+		return fmt.Sprintf("line %d", line)
+	}
+	return "an earlier write"
+}
+
+// IsOverlapError returns true if the given error, or any error it wraps, was
+// caused by overlapping data.
+func IsOverlapError(err error) bool {
+	var oerr *OverlapError
+	return errors.As(err, &oerr)
+}
+
+// origin identifies where loaded data came from.
+type origin struct {
+	line     int
+	source   string
+	priority int
+}
+
+// owner records where the data of a range of addresses came from.
+type owner struct {
+	Range
+	origin
+}
+
+// overlapLoader writes segments into an image while keeping track of where
+// the data at each address came from so overlaps can be detected and reported.
+// Data is never replaced by data of a lower priority, the policy only applies
+// to data of equal priority.
+type overlapLoader struct {
+	img    *Image
+	policy OverlapPolicy
+	owners []owner // sorted and non-overlapping
+}
+
+func (l *overlapLoader) load(seg Segment, org origin) error {
+	if len(seg.Data) == 0 {
+		return nil
+	}
+	if seg.end() > addressSpaceSize {
+		return fmt.Errorf("data at 0x%08X from %s extends past the 32-bit address space", seg.Address, describeOrigin(org.source, org.line))
+	}
+
+	var (
+		r = seg.Range()
+		i = sort.Search(len(l.owners), func(i int) bool {
+			return l.owners[i].High >= r.Low
+		})
+		j = i
+	)
+	for j < len(l.owners) && l.owners[j].Low <= r.High {
+		j++
+	}
+
+	// Decide for each overlapping range whether its data is kept, checking
+	// data of equal priority against the policy
+	keep := make([]owner, 0)
+	for _, o := range l.owners[i:j] {
+		if o.priority > org.priority {
+			keep = append(keep, o)
+			continue
+		}
+		if o.priority < org.priority {
+			continue
+		}
+
+		overlap := Range{maxAddress(o.Low, r.Low), minAddress(o.High, r.High)}
+		err := &OverlapError{overlap, o.line, org.line, o.source, org.source}
+
+		switch l.policy {
+		case OverlapReject:
+			return err
+
+		case OverlapRejectDiffering:
+			existing := make([]byte, overlap.Size())
+			l.img.ReadAt(existing, int64(overlap.Low))
+			if !bytes.Equal(existing, seg.Data[overlap.Low-r.Low:overlap.High-r.Low+1]) {
+				return err
+			}
+			keep = append(keep, o)
+
+		case OverlapFirstWins:
+			keep = append(keep, o)
+		}
+	}
+
+	// Write the data around the kept ranges
+	var (
+		owners = make([]owner, 0, len(keep)*2+3)
+		pieces = make([]Range, 0, len(keep)+1)
+		next   = uint64(r.Low)
+	)
+	for _, o := range keep {
+		if low := maxAddress(o.Low, r.Low); uint64(low) > next {
+			pieces = append(pieces, Range{uint32(next), low - 1})
+		}
+		owners = append(owners, o)
+		next = uint64(o.High) + 1
+	}
+	if next <= uint64(r.High) {
+		pieces = append(pieces, Range{uint32(next), r.High})
+	}
+	for _, p := range pieces {
+		_, err := l.img.WriteAt(seg.Data[p.Low-r.Low:p.High-r.Low+1], int64(p.Low))
+		if err != nil {
+			return err
+		}
+		owners = append(owners, owner{p, org})
+	}
+
+	// Keep what is left of replaced ranges sticking out of the segment
+	if i < j && l.owners[i].Low < r.Low && (len(keep) == 0 || keep[0] != l.owners[i]) {
+		owners = append(owners, owner{Range{l.owners[i].Low, r.Low - 1}, l.owners[i].origin})
+	}
+	if i < j && l.owners[j-1].High > r.High && (len(keep) == 0 || keep[len(keep)-1] != l.owners[j-1]) {
+		owners = append(owners, owner{Range{r.High + 1, l.owners[j-1].High}, l.owners[j-1].origin})
+	}
+	sort.Slice(owners, func(a, b int) bool {
+		return owners[a].Low < owners[b].Low
+	})
+
+	merged := make([]owner, 0, len(l.owners)-(j-i)+len(owners))
+	merged = append(merged, l.owners[:i]...)
+	merged = append(merged, owners...)
+	merged = append(merged, l.owners[j:]...)
+	l.owners = merged
+
+	return nil
+}
+
+func minAddress(a, b uint32) uint32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxAddress(a, b uint32) uint32 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReadImageWithPolicy(t *testing.T) {
+	const overlapping = `
+:0400000001020304F2
+:0400020005060708E0
+:00000001FF`
+
+	const identical = `
+:0400000001020304F2
+:020002000304F5
+:00000001FF`
+
+	var cases = []struct {
+		input     string
+		policy    OverlapPolicy
+		expectErr bool
+		data      []byte
+	}{
+		{overlapping, OverlapReject, true, nil},
+		{overlapping, OverlapRejectDiffering, true, nil},
+		{overlapping, OverlapFirstWins, false, decodeHex("010203040708")},
+		{overlapping, OverlapLastWins, false, decodeHex("010205060708")},
+		{identical, OverlapReject, true, nil},
+		{identical, OverlapRejectDiffering, false, decodeHex("01020304")},
+	}
+
+	for i, tc := range cases {
+		t.Logf("Case %d", i)
+
+		img, err := ReadImageWithPolicy(NewScanner(strings.NewReader(tc.input)), tc.policy)
+		if tc.expectErr {
+			if !IsOverlapError(err) {
+				t.Errorf("expected overlap error but got %v", err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+
+		data := make([]byte, len(tc.data))
+		if _, err := img.ReadAt(data, 0); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		} else if !bytes.Equal(data, tc.data) {
+			t.Errorf("data mismatch: expected=%X, actual=%X", tc.data, data)
+		}
+	}
+}
+
+func TestOverlapErrorPosition(t *testing.T) {
+	r := strings.NewReader(`:0400000001020304F2
+:02000000AABB99
+:0400100001020304E2
+:0400120005060708D0
+:00000001FF`)
+
+	_, err := ReadImage(NewScanner(r))
+	oerr, ok := err.(*OverlapError)
+	if !ok {
+		t.Fatalf("expected overlap error but got %v", err)
+	}
+	if oerr.Range != (Range{0x0000, 0x0001}) {
+		t.Errorf("range mismatch: expected=0x00000000-0x00000001, actual=%v", oerr.Range)
+	}
+	if oerr.FirstLine != 1 || oerr.SecondLine != 2 {
+		t.Errorf("line mismatch: expected=1,2, actual=%d,%d", oerr.FirstLine, oerr.SecondLine)
+	}
+}
+
+func TestOverlapFirstWinsHoles(t *testing.T) {
+	var (
+		img    Image
+		loader = &overlapLoader{img: &img, policy: OverlapFirstWins}
+	)
+	loader.load(Segment{0x02, decodeHex("AA")}, origin{line: 1})
+	loader.load(Segment{0x04, decodeHex("BB")}, origin{line: 2})
+	loader.load(Segment{0x00, decodeHex("000102030405")}, origin{line: 3})
+
+	data := make([]byte, 6)
+	img.ReadAt(data, 0)
+	if !bytes.Equal(data, decodeHex("0001AA03BB05")) {
+		t.Errorf("data mismatch: expected=0001AA03BB05, actual=%X", data)
+	}
+
+	err := (&overlapLoader{img: &img, policy: OverlapReject, owners: loader.owners}).load(Segment{0x03, decodeHex("FF")}, origin{line: 4})
+	if oerr, ok := err.(*OverlapError); !ok || oerr.FirstLine != 3 {
+		t.Errorf("expected overlap with line 3 but got %v", err)
+	}
+}
@@ -0,0 +1,257 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// LineRecord is a decoded record along with where it was found in the input.
+type LineRecord struct {
+	Record
+
+	// Line is the 1-based line number of the record.
+	Line int
+	// Offset is the byte offset of the record's start code in the input.
+	Offset int64
+	// AbsoluteAddress is the record's address with the extended segment or
+	// linear address base in effect applied to it.
+	AbsoluteAddress uint32
+}
+
+// ScanMode is a set of flags controlling how strictly RecordReader and Scanner
+// treat their input.
+type ScanMode uint
+
+const (
+	// ScanLenient records each problem in the input as a diagnostic and keeps
+	// scanning instead of failing at the first one. Lines that can't be
+	// decoded are skipped, but records with a bad checksum are still
+	// returned.
+	ScanLenient ScanMode = 1 << iota
+
+	// ScanFlatAddressing places the data of a record that runs past the end
+	// of its 64 KiB segment at the following addresses, as some tools do,
+	// instead of wrapping it around to the start of the segment as the
+	// Intel HEX specification requires.
+	ScanFlatAddressing
+
+	// ScanMultipleEOF treats several EOF terminated sections in one input,
+	// such as concatenated files, as a single image. The address base is
+	// reset to 0 after each EOF record.
+	ScanMultipleEOF
+
+	// ScanStrictEOF fails on any content following the EOF record instead of
+	// ignoring it. It has no effect together with ScanMultipleEOF.
+	ScanStrictEOF
+
+	// ScanAllowMissingEOF accepts input that ends without an EOF record,
+	// reporting it as a warning instead of failing.
+	ScanAllowMissingEOF
+)
+
+// RecordReader reads the individual records of an Intel HEX file, including
+// address, start address and EOF records. Unlike Scanner it does not stop at
+// the EOF record, it returns every record until the input is exhausted.
+type RecordReader struct {
+	lines    *lineReader
+	firstErr error
+
+	extendedSegmentedAddressBase uint32
+	extendedLinearAddressBase    uint32
+	linear                       bool
+
+	record LineRecord
+
+	mode        ScanMode
+	diagnostics []*ParseError
+	warnings    []*ParseError
+}
+
+func NewRecordReader(r io.Reader) *RecordReader {
+	return &RecordReader{
+		lines: newLineReader(r),
+	}
+}
+
+func (rr *RecordReader) Err() error {
+	return rr.firstErr
+}
+
+// SetMode sets the scan mode. It must be called before the first call to Scan.
+func (rr *RecordReader) SetMode(mode ScanMode) {
+	rr.mode = mode
+}
+
+// SetMaxLineLength sets the longest line accepted, which defaults to
+// DefaultMaxLineLength. Longer lines cause a ParseError for which errors.Is
+// returns true with ErrLineTooLong. Limits below 1 are treated as 1. It must be
+// called before the first call to Scan.
+func (rr *RecordReader) SetMaxLineLength(max int) {
+	rr.lines.setMax(max)
+}
+
+// Diagnostics returns the problems found so far in lenient mode that would
+// otherwise have stopped the scan.
+func (rr *RecordReader) Diagnostics() []*ParseError {
+	return rr.diagnostics
+}
+
+// Warnings returns the noteworthy but valid or explicitly allowed input found
+// so far.
+func (rr *RecordReader) Warnings() []*ParseError {
+	return rr.warnings
+}
+
+// diagnose records a problem skipped in lenient mode.
+func (rr *RecordReader) diagnose(err *ParseError) {
+	rr.diagnostics = append(rr.diagnostics, err)
+}
+
+// warn records input that doesn't stop the scan in any mode.
+func (rr *RecordReader) warn(err *ParseError) {
+	rr.warnings = append(rr.warnings, err)
+}
+
+// fail handles an error at a column of the current line. In lenient mode it is
+// recorded as a diagnostic and scanning continues, otherwise it stops
+// scanning.
+func (rr *RecordReader) fail(column int, err error) (stop bool) {
+	return rr.failWith(&ParseError{
+		Line:   rr.lines.line,
+		Column: column,
+		Text:   string(rr.lines.text),
+		Err:    err,
+	})
+}
+
+func (rr *RecordReader) failWith(perr *ParseError) (stop bool) {
+	if rr.mode&ScanLenient != 0 {
+		rr.diagnose(perr)
+		return false
+	}
+	rr.firstErr = perr
+	return true
+}
+
+// Scan advances to the next record, which will then be available through the
+// Record method. It returns false when the input is exhausted or an error
+// occurred.
+func (rr *RecordReader) Scan() bool {
+	if rr.firstErr != nil {
+		return false
+	}
+
+	for rr.lines.next() {
+		if rr.lines.tooLong {
+			if rr.failWith(rr.lines.lineTooLongError()) {
+				return false
+			}
+			continue
+		}
+
+		hexData := rr.lines.text
+		if len(hexData) == 0 {
+			continue // skip empty lines
+		}
+
+		// Check for the start code
+		if hexData[0] != StartCode {
+			err := fmt.Errorf("%w: expected %c but got %c", ErrStartCode, StartCode, hexData[0])
+			if rr.fail(1, err) {
+				return false
+			}
+			continue
+		}
+
+		dst, err := decodeHexDigits(hexData[1:])
+		if err != nil {
+			column := len(hexData)
+			if herr, ok := err.(hexDigitError); ok {
+				column = herr.index + 2
+			}
+			if rr.fail(column, err) {
+				return false
+			}
+			continue
+		}
+
+		// Decode the record, keeping records that only have a bad checksum in
+		// lenient mode
+		var record Record
+		if err := (&record).UnmarshalBinary(dst); err != nil {
+			if rr.fail(recordErrorColumn(err, len(hexData)), err) {
+				return false
+			}
+			if !IsChecksumError(err) {
+				continue
+			}
+		}
+
+		var addressBase uint32
+
+		// Only one should be non-zero at a time so the order shouldn't matter
+		if rr.extendedSegmentedAddressBase != 0 {
+			addressBase = rr.extendedSegmentedAddressBase
+		}
+		if rr.extendedLinearAddressBase != 0 {
+			addressBase = rr.extendedLinearAddressBase
+		}
+
+		rr.record = LineRecord{
+			Record:          record,
+			Line:            rr.lines.line,
+			Offset:          rr.lines.lineOffset,
+			AbsoluteAddress: addressBase + uint32(record.Address),
+		}
+
+		switch record.RecordType {
+		case RecordTypeExtSegAddr:
+			rr.extendedSegmentedAddressBase = ((uint32(record.Data[0]) << 8) | uint32(record.Data[1])) << 4
+			rr.extendedLinearAddressBase = 0
+			rr.linear = false
+
+		case RecordTypeExtLinAddr:
+			rr.extendedSegmentedAddressBase = 0
+			rr.extendedLinearAddressBase = ((uint32(record.Data[0]) << 8) | uint32(record.Data[1])) << 16
+			rr.linear = true
+		}
+
+		return true
+	}
+
+	rr.firstErr = rr.lines.Err()
+	return false
+}
+
+// resetAddressBase forgets the address base set by previous address records.
+func (rr *RecordReader) resetAddressBase() {
+	rr.extendedSegmentedAddressBase = 0
+	rr.extendedLinearAddressBase = 0
+	rr.linear = false
+}
+
+// recordErrorColumn returns the column of the field of a line of the given
+// length that caused an error from Record.UnmarshalBinary.
+func recordErrorColumn(err error, length int) int {
+	switch {
+	case errors.Is(err, ErrChecksum):
+		return length - 1
+	case errors.Is(err, ErrInvalidRecordType):
+		return 8
+	}
+	return 2
+}
+
+// Record returns the most recent record read by Scan.
+func (rr *RecordReader) Record() LineRecord {
+	return rr.record
+}
+
+// Line returns the number of lines read so far.
+func (rr *RecordReader) Line() int {
+	return rr.lines.line
+}
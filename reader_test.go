@@ -0,0 +1,79 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRecordReader(t *testing.T) {
+	r := strings.NewReader(":10010000214601360121470136007EFE09D2190140\r\n" +
+		"\r\n" +
+		":020000040001F9\r\n" +
+		":100110002146017E17C20001FF5F16002148011928\r\n" +
+		":00000001FF\r\n" +
+		":10012000194E79234623965778239EDA3F01B2CAA7\r\n")
+
+	expected := []struct {
+		recordType      byte
+		line            int
+		offset          int64
+		absoluteAddress uint32
+	}{
+		{RecordTypeData, 1, 0, 0x00000100},
+		{RecordTypeExtLinAddr, 3, 47, 0x00000000},
+		{RecordTypeData, 4, 64, 0x00010110},
+		{RecordTypeEOF, 5, 109, 0x00010000},
+		{RecordTypeData, 6, 122, 0x00010120},
+	}
+
+	var (
+		rr = NewRecordReader(r)
+		i  int
+	)
+	for rr.Scan() {
+		if i >= len(expected) {
+			t.Fatalf("unexpected record %d", i)
+		}
+
+		record := rr.Record()
+		exp := expected[i]
+		if record.RecordType != exp.recordType {
+			t.Errorf("[record %d] record type mismatch: expected=0x%02X, actual=0x%02X", i, exp.recordType, record.RecordType)
+		}
+		if record.Line != exp.line {
+			t.Errorf("[record %d] line mismatch: expected=%d, actual=%d", i, exp.line, record.Line)
+		}
+		if record.Offset != exp.offset {
+			t.Errorf("[record %d] offset mismatch: expected=%d, actual=%d", i, exp.offset, record.Offset)
+		}
+		if record.AbsoluteAddress != exp.absoluteAddress {
+			t.Errorf("[record %d] absolute address mismatch: expected=0x%08X, actual=0x%08X", i, exp.absoluteAddress, record.AbsoluteAddress)
+		}
+		i++
+	}
+	if err := rr.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if i != len(expected) {
+		t.Errorf("record count mismatch: expected=%d, actual=%d", len(expected), i)
+	}
+}
+
+func TestRecordReaderError(t *testing.T) {
+	rr := NewRecordReader(strings.NewReader(":00000001FF\n:04000005000000CD2B\n"))
+	if !rr.Scan() {
+		t.Fatalf("expected first record, got error: %v", rr.Err())
+	}
+	if rr.Scan() {
+		t.Fatal("expected scan to fail on bad checksum")
+	}
+	if !IsChecksumError(rr.Err()) {
+		t.Errorf("expected checksum error but got %v", rr.Err())
+	}
+	if rr.Line() != 2 {
+		t.Errorf("expected error on line 2 but got %d", rr.Line())
+	}
+}
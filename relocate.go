@@ -0,0 +1,86 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"fmt"
+)
+
+// Relocate adds offset to the address of every segment. If any segment would
+// be moved outside the 32-bit address space it returns an error and leaves the
+// segments unchanged.
+func (ss SegmentSlice) Relocate(offset int64) error {
+	for _, s := range ss {
+		if err := checkRelocation(s, offset); err != nil {
+			return err
+		}
+	}
+	for _, s := range ss {
+		s.Address = uint32(int64(s.Address) + offset)
+	}
+	return nil
+}
+
+func checkRelocation(s *Segment, offset int64) error {
+	address := int64(s.Address) + offset
+	if address < 0 || address+int64(len(s.Data)) > addressSpaceSize {
+		return fmt.Errorf("relocating data at 0x%08X by %d moves it outside the 32-bit address space", s.Address, offset)
+	}
+	return nil
+}
+
+// Relocate adds offset to every address of the image's data. If any data would
+// be moved outside the 32-bit address space it returns an error and leaves the
+// image unchanged. The start address is left unchanged.
+func (img *Image) Relocate(offset int64) error {
+	if len(img.segments) == 0 {
+		return nil
+	}
+	if err := checkRelocation(img.segments[0], offset); err != nil {
+		return err
+	}
+	if err := checkRelocation(img.segments[len(img.segments)-1], offset); err != nil {
+		return err
+	}
+
+	segments := make(SegmentSlice, len(img.segments))
+	for i, s := range img.segments {
+		segments[i] = &Segment{uint32(int64(s.Address) + offset), s.Data}
+	}
+	img.segments = segments
+	return nil
+}
+
+// Remap moves the data within the address window from so that the window
+// starts at address to, leaving the data outside of it in place. It returns an
+// error if the window would be moved past the end of the 32-bit address space
+// and an error for which IsOverlapError returns true if the moved data would
+// overlap data outside of the window. In either case the image is left
+// unchanged. The start address is left unchanged.
+func (img *Image) Remap(from Range, to uint32) error {
+	if from.High < from.Low {
+		return fmt.Errorf("invalid address window %v", from)
+	}
+	if uint64(to)+uint64(from.High-from.Low) >= addressSpaceSize {
+		return fmt.Errorf("remapping %v to 0x%08X moves it past the end of the 32-bit address space", from, to)
+	}
+
+	var (
+		inside, outside = img.split(from)
+		remapped        = &Image{segments: outside}
+		loader          = &overlapLoader{img: remapped, policy: OverlapReject}
+	)
+	for _, r := range remapped.Ranges() {
+		loader.owners = append(loader.owners, owner{Range: r})
+	}
+	for _, s := range inside {
+		seg := Segment{s.Address - from.Low + to, s.Data}
+		if err := loader.load(seg, origin{source: from.String()}); err != nil {
+			return err
+		}
+	}
+
+	img.segments = remapped.segments
+	return nil
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSegmentSliceRelocate(t *testing.T) {
+	ss := SegmentSlice{
+		{0x08000000, decodeHex("0102")},
+		{0x08001000, decodeHex("0304")},
+	}
+
+	if err := ss.Relocate(-0x08000000); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ss[0].Address != 0x0000 || ss[1].Address != 0x1000 {
+		t.Errorf("address mismatch: expected=0x0000,0x1000, actual=0x%X,0x%X", ss[0].Address, ss[1].Address)
+	}
+
+	// A failed relocation leaves every segment in place
+	if err := ss.Relocate(-0x0001); err == nil {
+		t.Errorf("expected error")
+	}
+	if ss[0].Address != 0x0000 || ss[1].Address != 0x1000 {
+		t.Errorf("address mismatch: expected=0x0000,0x1000, actual=0x%X,0x%X", ss[0].Address, ss[1].Address)
+	}
+}
+
+func TestImageRelocate(t *testing.T) {
+	var cases = []struct {
+		offset int64
+		ranges []Range
+		fail   bool
+	}{
+		{
+			offset: 0x08000000,
+			ranges: []Range{{0x08000000, 0x08000003}, {0x08001000, 0x08001001}},
+		},
+		{
+			offset: 0xFFFFEFFE,
+			ranges: []Range{{0xFFFFEFFE, 0xFFFFF001}, {0xFFFFFFFE, 0xFFFFFFFF}},
+		},
+		{
+			offset: 0xFFFFEFFF,
+			fail:   true,
+		},
+		{
+			offset: -1,
+			fail:   true,
+		},
+	}
+
+	for i, tc := range cases {
+		t.Logf("Case %d", i)
+
+		var img Image
+		img.WriteAt(decodeHex("01020304"), 0x0000)
+		img.WriteAt(decodeHex("0506"), 0x1000)
+
+		err := img.Relocate(tc.offset)
+		if tc.fail {
+			if err == nil {
+				t.Errorf("expected error")
+			}
+			tc.ranges = []Range{{0x0000, 0x0003}, {0x1000, 0x1001}}
+		} else if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		ranges := img.Ranges()
+		if len(ranges) != len(tc.ranges) {
+			t.Errorf("range mismatch: expected=%v, actual=%v", tc.ranges, ranges)
+			continue
+		}
+		for j := range ranges {
+			if ranges[j] != tc.ranges[j] {
+				t.Errorf("range mismatch: expected=%v, actual=%v", tc.ranges, ranges)
+				break
+			}
+		}
+	}
+}
+
+func TestImageRemap(t *testing.T) {
+	newImage := func() *Image {
+		img := new(Image)
+		img.WriteAt(decodeHex("0102030405060708"), 0x1000)
+		img.WriteAt(decodeHex("AABB"), 0x3000)
+		return img
+	}
+
+	// Move the middle of a segment into a free window
+	img := newImage()
+	if err := img.Remap(Range{0x1002, 0x1005}, 0x2000); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var (
+		expected = []Range{{0x1000, 0x1001}, {0x1006, 0x1007}, {0x2000, 0x2003}, {0x3000, 0x3001}}
+		ranges   = img.Ranges()
+	)
+	if len(ranges) != len(expected) {
+		t.Fatalf("range mismatch: expected=%v, actual=%v", expected, ranges)
+	}
+	for i := range ranges {
+		if ranges[i] != expected[i] {
+			t.Errorf("range mismatch: expected=%v, actual=%v", expected, ranges)
+			break
+		}
+	}
+	data := make([]byte, 4)
+	img.ReadAt(data, 0x2000)
+	if !bytes.Equal(data, decodeHex("03040506")) {
+		t.Errorf("data mismatch: expected=03040506, actual=%X", data)
+	}
+
+	// Moving a window onto itself shifted by less than its size is fine
+	img = newImage()
+	if err := img.Remap(Range{0x1000, 0x1FFF}, 0x1004); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ranges := img.Ranges(); ranges[0] != (Range{0x1004, 0x100B}) {
+		t.Errorf("range mismatch: expected=0x00001004-0x0000100B, actual=%v", ranges[0])
+	}
+
+	// Moving data onto data outside the window fails and changes nothing
+	img = newImage()
+	if err := img.Remap(Range{0x3000, 0x3FFF}, 0x1006); !IsOverlapError(err) {
+		t.Errorf("expected overlap error but got %v", err)
+	}
+	if ranges := img.Ranges(); len(ranges) != 2 || ranges[0] != (Range{0x1000, 0x1007}) {
+		t.Errorf("image changed by failed remap: %v", ranges)
+	}
+
+	// The window must fit into the address space at its destination
+	img = newImage()
+	if err := img.Remap(Range{0x1000, 0x1FFF}, 0xFFFFF001); err == nil {
+		t.Errorf("expected error")
+	}
+}
@@ -0,0 +1,392 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SRecordStartCode is the byte that each Motorola S-record line is expected
+// to start with.
+const SRecordStartCode = 'S'
+
+// Motorola S-record types.
+const (
+	SRecordTypeHeader  = 0
+	SRecordTypeData16  = 1
+	SRecordTypeData24  = 2
+	SRecordTypeData32  = 3
+	SRecordTypeCount16 = 5
+	SRecordTypeCount24 = 6
+	SRecordTypeStart32 = 7
+	SRecordTypeStart24 = 8
+	SRecordTypeStart16 = 9
+)
+
+// sRecordAddressSizes holds the number of address bytes of each record type,
+// 0 for reserved types.
+var sRecordAddressSizes = [10]int{2, 2, 3, 4, 0, 2, 3, 4, 3, 2}
+
+// SRecord is a single Motorola S-record.
+type SRecord struct {
+	Type     byte
+	Address  uint32
+	Data     []byte
+	Checksum byte
+}
+
+// SRecordChecksum returns the one's complement checksum of the byte count,
+// address and data fields.
+func SRecordChecksum(data []byte) byte {
+	var sum uint
+	for _, b := range data {
+		sum += uint(b)
+	}
+	return byte(^sum)
+}
+
+// NewSRecord returns a record of the given type with its checksum calculated.
+func NewSRecord(recordType byte, address uint32, data []byte) (record *SRecord) {
+	record = new(SRecord)
+	record.Type = recordType
+	record.Address = address
+	record.Data = make([]byte, len(data))
+	copy(record.Data, data)
+
+	record.MarshalText()
+
+	return
+}
+
+// MarshalText encodes a record into a line of text without a line ending. It
+// also calculates and fixes the record's checksum.
+func (x *SRecord) MarshalText() (text []byte, err error) {
+	if x.Type > 9 || sRecordAddressSizes[x.Type] == 0 {
+		err = invalidRecordTypeError(x.Type)
+		return
+	}
+
+	addressSize := sRecordAddressSizes[x.Type]
+	if addressSize < 4 && x.Address>>(uint(addressSize)*8) != 0 {
+		err = fmt.Errorf("address 0x%08X does not fit record type S%d", x.Address, x.Type)
+		return
+	}
+	if len(x.Data)+addressSize+1 > 0xFF {
+		err = fmt.Errorf("%d data bytes do not fit record type S%d", len(x.Data), x.Type)
+		return
+	}
+
+	buf := make([]byte, 0, 1+addressSize+len(x.Data)+1)
+	buf = append(buf, byte(addressSize+len(x.Data)+1))
+	for i := addressSize - 1; i >= 0; i-- {
+		buf = append(buf, byte(x.Address>>(uint(i)*8)))
+	}
+	buf = append(buf, x.Data...)
+
+	// Calculate the checksum
+	x.Checksum = SRecordChecksum(buf)
+	buf = append(buf, x.Checksum)
+
+	text = []byte(fmt.Sprintf("%c%d%s", SRecordStartCode, x.Type, strings.ToUpper(hex.EncodeToString(buf))))
+	return
+}
+
+// UnmarshalText decodes a record from a line of text or returns an error. The
+// functions IsChecksumError or IsInvalidRecordTypeError can be used to
+// determine the type of error.
+func (x *SRecord) UnmarshalText(text []byte) error {
+	if len(text) < 2 {
+		return recordLengthError("record too short")
+	}
+
+	// Check for the start code
+	if text[0] != SRecordStartCode {
+		return fmt.Errorf("%w: expected %c but got %c", ErrStartCode, SRecordStartCode, text[0])
+	}
+
+	if text[1] < '0' || text[1] > '9' {
+		return invalidRecordTypeError(text[1])
+	}
+	if sRecordAddressSizes[text[1]-'0'] == 0 {
+		return invalidRecordTypeError(text[1] - '0')
+	}
+	x.Type = text[1] - '0'
+
+	data, err := decodeHexDigits(text[2:])
+	if err != nil {
+		return err
+	}
+
+	addressSize := sRecordAddressSizes[x.Type]
+	if len(data) < 1+addressSize+1 {
+		return recordLengthError(fmt.Sprintf("record too short for type S%d", x.Type))
+	}
+	if int(data[0]) != len(data)-1 {
+		return byteCountMismatchError{int(data[0]), len(data) - 1}
+	}
+
+	x.Address = 0
+	for _, b := range data[1 : 1+addressSize] {
+		x.Address = x.Address<<8 | uint32(b)
+	}
+
+	x.Data = make([]byte, len(data)-addressSize-2)
+	copy(x.Data, data[1+addressSize:])
+	x.Checksum = data[len(data)-1]
+
+	// Validate the checksum
+	calculated := SRecordChecksum(data[:len(data)-1])
+	if calculated != x.Checksum {
+		return checksumError{x.Checksum, calculated}
+	}
+
+	return nil
+}
+
+// SRecordScanner reads the data of a Motorola S-record file as segments. It
+// validates the S5/S6 record counts and stops at the S7/S8/S9 termination
+// record.
+type SRecordScanner struct {
+	lines    *lineReader
+	firstErr error
+	done     bool
+
+	count uint32
+
+	segment Segment
+	line    int
+	header  []byte
+	start   *StartAddress
+}
+
+func NewSRecordScanner(r io.Reader) *SRecordScanner {
+	return &SRecordScanner{
+		lines: newLineReader(r),
+	}
+}
+
+func (s *SRecordScanner) Err() error {
+	return s.firstErr
+}
+
+func (s *SRecordScanner) Scan() bool {
+	if s.firstErr != nil || s.done {
+		return false
+	}
+
+	for s.lines.next() {
+		if s.lines.tooLong {
+			s.firstErr = s.lines.lineTooLongError()
+			return false
+		}
+
+		text := bytes.TrimSpace(s.lines.text)
+		if len(text) == 0 {
+			continue // skip empty lines
+		}
+
+		// Decode the record
+		var record SRecord
+		if err := (&record).UnmarshalText(text); err != nil {
+			s.firstErr = &ParseError{Line: s.lines.line, Text: string(s.lines.text), Err: err}
+			return false
+		}
+
+		switch record.Type {
+		case SRecordTypeHeader:
+			s.header = record.Data
+
+		case SRecordTypeData16, SRecordTypeData24, SRecordTypeData32:
+			s.count++
+
+			s.segment.Address = record.Address
+			s.segment.Data = record.Data
+			s.line = s.lines.line
+
+			return true
+
+		case SRecordTypeCount16, SRecordTypeCount24:
+			if record.Address != s.count {
+				s.firstErr = &ParseError{
+					Line: s.lines.line,
+					Text: string(s.lines.text),
+					Err:  fmt.Errorf("record count was %d but %d data records were read", record.Address, s.count),
+				}
+				return false
+			}
+
+		case SRecordTypeStart32, SRecordTypeStart24, SRecordTypeStart16:
+			s.start = NewStartLinearAddress(record.Address)
+			s.done = true
+			return false // return with no error
+		}
+	}
+
+	s.firstErr = s.lines.Err()
+	if s.firstErr == nil {
+		s.firstErr = &ParseError{Line: s.lines.line, Err: ErrMissingEOF}
+	}
+
+	return false
+}
+
+// SetMaxLineLength sets the longest line accepted, which defaults to
+// DefaultMaxLineLength. Limits below 1 are treated as 1. It must be called
+// before the first call to Scan.
+func (s *SRecordScanner) SetMaxLineLength(max int) {
+	s.lines.setMax(max)
+}
+
+func (s *SRecordScanner) Segment() Segment {
+	return s.segment
+}
+
+// Line returns the line number of the data record of the current segment.
+func (s *SRecordScanner) Line() int {
+	return s.line
+}
+
+// Header returns the data of the S0 header record or nil if there was none.
+func (s *SRecordScanner) Header() []byte {
+	return s.header
+}
+
+// StartAddress returns the address of the termination record. It is nil until
+// Scan has returned false without an error, and it is also nil if the
+// termination record's address is 0 since most tools write 0 when there is no
+// start address.
+func (s *SRecordScanner) StartAddress() *StartAddress {
+	if s.start == nil || s.start.Value == 0 {
+		return nil
+	}
+	start := *s.start
+	return &start
+}
+
+// SRecordEncoder writes segments in Motorola S-record format. The exported
+// fields can be changed to customize the output before the first call to
+// Encode.
+type SRecordEncoder struct {
+	w io.Writer
+
+	// RecordSize is the maximum number of data bytes per data record.
+	RecordSize int
+	// AddressSize is the number of address bytes of the data records: 2 for
+	// S1, 3 for S2 or 4 for S3. If it is 0 the smallest size that fits all
+	// segments is used.
+	AddressSize int
+	// Header is written as an S0 record if it is not nil.
+	Header []byte
+	// WriteCount writes an S5 or S6 record with the number of data records.
+	WriteCount bool
+	// UseCRLF ends lines with \r\n instead of \n.
+	UseCRLF bool
+	// StartAddress is written in the termination record if it is not nil.
+	StartAddress *StartAddress
+}
+
+// NewSRecordEncoder returns an encoder writing to w with DefaultRecordSize
+// records and automatically sized addresses.
+func NewSRecordEncoder(w io.Writer) *SRecordEncoder {
+	return &SRecordEncoder{
+		w:          w,
+		RecordSize: DefaultRecordSize,
+	}
+}
+
+// Encode writes the header, the segments in order, the optional count record
+// and the termination record.
+func (e *SRecordEncoder) Encode(segments SegmentSlice) error {
+	addressSize := e.AddressSize
+	if addressSize == 0 {
+		addressSize = 2
+		for _, seg := range segments {
+			if len(seg.Data) == 0 {
+				continue
+			}
+			if last := seg.end() - 1; last > 0xFFFFFF {
+				addressSize = 4
+			} else if last > 0xFFFF && addressSize < 3 {
+				addressSize = 3
+			}
+		}
+	}
+	if addressSize < 2 || addressSize > 4 {
+		return fmt.Errorf("address size must be 2, 3 or 4 but got %d", addressSize)
+	}
+
+	maxRecordSize := 0xFF - addressSize - 1
+	if e.RecordSize < 1 || e.RecordSize > maxRecordSize {
+		return fmt.Errorf("record size must be between 1 and %d but got %d", maxRecordSize, e.RecordSize)
+	}
+
+	if e.Header != nil {
+		if err := e.writeRecord(NewSRecord(SRecordTypeHeader, 0, e.Header)); err != nil {
+			return err
+		}
+	}
+
+	var (
+		dataType = byte(addressSize - 1)
+		count    uint32
+	)
+	for _, seg := range segments {
+		if seg.end() > addressSpaceSize {
+			return fmt.Errorf("segment at 0x%08X extends past the 32-bit address space", seg.Address)
+		}
+
+		for offset := 0; offset < len(seg.Data); offset += e.RecordSize {
+			n := len(seg.Data) - offset
+			if n > e.RecordSize {
+				n = e.RecordSize
+			}
+			record := &SRecord{
+				Type:    dataType,
+				Address: seg.Address + uint32(offset),
+				Data:    seg.Data[offset : offset+n],
+			}
+			if err := e.writeRecord(record); err != nil {
+				return err
+			}
+			count++
+		}
+	}
+
+	if e.WriteCount {
+		countType := byte(SRecordTypeCount16)
+		if count > 0xFFFF {
+			countType = SRecordTypeCount24
+		}
+		if err := e.writeRecord(NewSRecord(countType, count, nil)); err != nil {
+			return err
+		}
+	}
+
+	// The termination record matches the data record type
+	var start uint32
+	if e.StartAddress != nil {
+		start = e.StartAddress.Address()
+	}
+	return e.writeRecord(&SRecord{Type: 10 - dataType, Address: start})
+}
+
+// writeRecord writes a single record as a line of text.
+func (e *SRecordEncoder) writeRecord(record *SRecord) error {
+	text, err := record.MarshalText()
+	if err != nil {
+		return err
+	}
+
+	eol := "\n"
+	if e.UseCRLF {
+		eol = "\r\n"
+	}
+
+	_, err = fmt.Fprintf(e.w, "%s%s", text, eol)
+	return err
+}
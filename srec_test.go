@@ -0,0 +1,192 @@
+// Copyright (c) 2018 Awarepoint Corporation. All rights reserved.
+// AWAREPOINT PROPRIETARY/CONFIDENTIAL. Use is subject to license terms.
+
+package intelhex
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// Example from https://en.wikipedia.org/wiki/SREC_(file_format)#16-bit_memory_address
+const srecExample = `S00F000068656C6C6F202020202000003C
+S11F00007C0802A6900100049421FFF07C6C1B787C8C23783C6000003863000026
+S11F001C4BFFFFE5398000007D83637880010014382100107C0803A64E800020E9
+S111003848656C6C6F20776F726C642E0A0042
+S5030003F9
+S9030000FC
+`
+
+func TestSRecordUnmarshalText(t *testing.T) {
+	var cases = []struct {
+		expectErr bool
+		text      string
+		record    SRecord
+	}{
+		{
+			false,
+			"S111003848656C6C6F20776F726C642E0A0042",
+			SRecord{
+				Type:     SRecordTypeData16,
+				Address:  0x0038,
+				Data:     []byte("Hello world.\n\x00"),
+				Checksum: 0x42,
+			},
+		},
+		{
+			false,
+			"S5030003F9",
+			SRecord{
+				Type:     SRecordTypeCount16,
+				Address:  0x0003,
+				Data:     []byte{},
+				Checksum: 0xF9,
+			},
+		},
+
+		// Test checksum error
+		{true, "S5030003F8", SRecord{}},
+		// Test reserved record type
+		{true, "S4030003F9", SRecord{}},
+		// Test byte count mismatch
+		{true, "S5040003F9", SRecord{}},
+		// Test bad start code
+		{true, "X5030003F9", SRecord{}},
+	}
+
+	for i, tc := range cases {
+		t.Logf("Case %d", i)
+
+		var r SRecord
+		err := (&r).UnmarshalText([]byte(tc.text))
+		if tc.expectErr {
+			if err == nil {
+				t.Error("expected error")
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		if r.Type != tc.record.Type {
+			t.Errorf("type mismatch: expected=S%d, actual=S%d", tc.record.Type, r.Type)
+		}
+		if r.Address != tc.record.Address {
+			t.Errorf("address mismatch: expected=0x%08X, actual=0x%08X", tc.record.Address, r.Address)
+		}
+		if !bytes.Equal(r.Data, tc.record.Data) {
+			t.Errorf("data mismatch: expected=%X, actual=%X", tc.record.Data, r.Data)
+		}
+		if r.Checksum != tc.record.Checksum {
+			t.Errorf("checksum mismatch: expected=0x%02X, actual=0x%02X", tc.record.Checksum, r.Checksum)
+		}
+
+		text, err := (&r).MarshalText()
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		} else if string(text) != tc.text {
+			t.Errorf("text mismatch: expected=%s, actual=%s", tc.text, text)
+		}
+	}
+}
+
+func TestSRecordScanner(t *testing.T) {
+	s := NewSRecordScanner(strings.NewReader(srecExample))
+
+	segments := make([]Segment, 0)
+	for s.Scan() {
+		segments = append(segments, s.Segment())
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(segments) != 3 {
+		t.Fatalf("expected 3 segments but got %d", len(segments))
+	}
+	if segments[2].Address != 0x0038 || !bytes.Equal(segments[2].Data, []byte("Hello world.\n\x00")) {
+		t.Errorf("unexpected last segment 0x%08X %X", segments[2].Address, segments[2].Data)
+	}
+	if string(s.Header()) != "hello     \x00\x00" {
+		t.Errorf("unexpected header %q", s.Header())
+	}
+	if s.StartAddress() != nil {
+		t.Errorf("expected no start address but got %v", s.StartAddress())
+	}
+}
+
+func TestSRecordScannerErrors(t *testing.T) {
+	var cases = []string{
+		// Missing termination record
+		"S111003848656C6C6F20776F726C642E0A0042\n",
+		// Wrong record count
+		"S111003848656C6C6F20776F726C642E0A0042\nS5030003F9\nS9030000FC\n",
+	}
+
+	for i, tc := range cases {
+		t.Logf("Case %d", i)
+
+		s := NewSRecordScanner(strings.NewReader(tc))
+		for s.Scan() {
+		}
+		if s.Err() == nil {
+			t.Error("expected error")
+		}
+	}
+}
+
+func TestSRecordEncoder(t *testing.T) {
+	img, err := ReadImage(NewSRecordScanner(strings.NewReader(srecExample)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	buf := &bytes.Buffer{}
+	e := NewSRecordEncoder(buf)
+	e.RecordSize = 28
+	e.Header = []byte("hello     \x00\x00")
+	e.WriteCount = true
+	if err := img.EncodeSRecords(e); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != srecExample {
+		t.Error("data mismatch")
+		t.Errorf("	expected=%s", srecExample)
+		t.Errorf("	  actual=%s", buf.String())
+	}
+}
+
+func TestSRecordEncoderAddressSize(t *testing.T) {
+	var cases = []struct {
+		segments SegmentSlice
+		start    *StartAddress
+		output   string
+	}{
+		{
+			SegmentSlice{{0x00012345, decodeHex("AABB")}},
+			nil,
+			"S206012345AABB2B\nS804000000FB\n",
+		},
+		{
+			SegmentSlice{{0x08000000, decodeHex("AABB")}},
+			NewStartLinearAddress(0x08000131),
+			"S30708000000AABB8B\nS70508000131C0\n",
+		},
+	}
+
+	for i, tc := range cases {
+		t.Logf("Case %d", i)
+
+		buf := &bytes.Buffer{}
+		e := NewSRecordEncoder(buf)
+		e.StartAddress = tc.start
+		if err := e.Encode(tc.segments); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		} else if buf.String() != tc.output {
+			t.Errorf("output mismatch: expected=%q, actual=%q", tc.output, buf.String())
+		}
+	}
+}